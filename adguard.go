@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"unipidns/internal/adguard"
+	"unipidns/internal/records"
+)
+
+// adGuardTarget publishes the records as AdGuard Home DNS rewrites
+type adGuardTarget struct {
+	name   string
+	client *adguard.Client
+}
+
+func newAdGuardTarget(target DnsTarget) *adGuardTarget {
+	return &adGuardTarget{
+		name:   target.Name,
+		client: adguard.NewClient(target.Url, target.Username, target.Password, &http.Client{Timeout: target.timeout()}),
+	}
+}
+
+func (a *adGuardTarget) displayName() string {
+	return "AdGuard Home: " + a.name
+}
+
+// sync reconciles the rewrites for names inside the local suffix and the configured domains, any
+// other rewrite was made by hand and is left alone
+func (a *adGuardTarget) sync(desired *desiredState) error {
+	rewrites, err := a.client.GetRewrites()
+	if err != nil {
+		return err
+	}
+	var current []records.Record
+	for _, rewrite := range rewrites {
+		// "A" and "AAAA" answers keep the upstream answer and are never written by this tool
+		if rewrite.Answer == records.A || rewrite.Answer == records.AAAA {
+			continue
+		}
+		current = append(current, rewriteRecord(rewrite))
+	}
+	fmt.Printf("	%d Rewrites Found\n", len(current))
+
+	add, remove := records.Diff(current, desired.records.Records, func(record records.Record) bool {
+		return desired.owns(record.Name)
+	})
+	fmt.Printf("	%d Rewrites to Add\n", len(add))
+	fmt.Printf("	%d Rewrites to Remove\n", len(remove))
+	if desired.dryRun {
+		printChanges(add, remove)
+		return nil
+	}
+
+	for _, record := range add {
+		err = a.client.AddRewrite(adguard.Rewrite{Domain: record.Name, Answer: record.Value})
+		if err != nil {
+			return err
+		}
+	}
+	for _, record := range remove {
+		err = a.client.DeleteRewrite(adguard.Rewrite{Domain: record.Name, Answer: record.Value})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteRecord turns a rewrite into the record it answers with
+func rewriteRecord(rewrite adguard.Rewrite) records.Record {
+	if _, err := netip.ParseAddr(rewrite.Answer); err == nil {
+		return records.Address(rewrite.Domain, rewrite.Answer)
+	}
+	return records.Cname(rewrite.Domain, rewrite.Answer)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"unipidns/internal/adguard"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+func TestAdGuardSync(t *testing.T) {
+	rewrites := []adguard.Rewrite{
+		{Domain: "nas.lan", Answer: "192.168.1.10"},
+		{Domain: "old.lan", Answer: "192.168.1.99"},
+		{Domain: "*.apps.awesome.com", Answer: "edge.lan"},
+		{Domain: "printer.home.arpa", Answer: "192.168.1.50"},
+		{Domain: "ads.example.net", Answer: "0.0.0.0"},
+		{Domain: "cdn.example.net", Answer: "A"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rewrite adguard.Rewrite
+		if r.Method == "POST" {
+			err := json.NewDecoder(r.Body).Decode(&rewrite)
+			if err != nil {
+				t.Errorf("Error decoding rewrite: %s", err)
+			}
+		}
+		switch r.URL.Path {
+		case "/control/rewrite/list":
+			responseJson, err := json.Marshal(rewrites)
+			if err != nil {
+				t.Errorf("Error marshalling response: %s", err)
+			}
+			w.Write(responseJson)
+		case "/control/rewrite/add":
+			rewrites = append(rewrites, rewrite)
+		case "/control/rewrite/delete":
+			rewrites = slices.DeleteFunc(rewrites, func(r adguard.Rewrite) bool { return r == rewrite })
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	desired := &desiredState{local: "lan", domains: []domains.Domain{{Name: "awesome.com"}}}
+	desired.records.Add(records.Address("nas.lan", "192.168.1.10"), records.Cname("files.awesome.com", "nas.lan"))
+	err := newAdGuardTarget(DnsTarget{source: source{Name: "test", Url: server.URL}}).sync(desired)
+	if err != nil {
+		t.Fatalf("Error syncing: %s", err)
+	}
+
+	expected := []adguard.Rewrite{
+		{Domain: "nas.lan", Answer: "192.168.1.10"},
+		{Domain: "printer.home.arpa", Answer: "192.168.1.50"},
+		{Domain: "ads.example.net", Answer: "0.0.0.0"},
+		{Domain: "cdn.example.net", Answer: "A"},
+		{Domain: "files.awesome.com", Answer: "nas.lan"},
+	}
+	if !slices.Equal(rewrites, expected) {
+		t.Errorf("Expected rewrites %v, got %v", expected, rewrites)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"unipidns/internal/nginxproxymanager"
+	"unipidns/internal/pihole"
+	"unipidns/internal/unificontroller"
+)
+
+// auditData is everything the upstreams are checked against
+type auditData struct {
+	local string
+	// fixed and dynamic clients keyed by IP address
+	fixed   map[string]unificontroller.Client
+	dynamic map[string]unificontroller.Client
+	// fixed clients keyed by every name they are published under
+	names map[string]unificontroller.Client
+	// records currently on the PiHoles
+	hosts  map[string][]string
+	cnames map[string]string
+}
+
+// runAudit checks the upstream of every Nginx Proxy Manager host against the Unifi clients and the
+// records on the PiHoles, and returns the exit code: 1 if any upstream needs attention.
+func runAudit(config *Config) int {
+	fmt.Println("Auditing Nginx Proxy Manager upstreams")
+	fmt.Println()
+
+	clients, _, err := unificontroller.GetAllClients(config.Unifi)
+	check(err)
+	data := auditData{
+		local:   config.Local,
+		fixed:   map[string]unificontroller.Client{},
+		dynamic: map[string]unificontroller.Client{},
+		names:   map[string]unificontroller.Client{},
+		hosts:   map[string][]string{},
+		cnames:  map[string]string{},
+	}
+	for _, client := range clients {
+		client.Name = fmt.Sprintf("%s.%s", strings.ToLower(client.Name), config.Local)
+		if !client.Fixed {
+			data.dynamic[client.Ip] = client
+			continue
+		}
+		data.fixed[client.Ip] = client
+		for _, ipv6 := range client.Ipv6 {
+			data.fixed[ipv6] = client
+		}
+		for _, name := range clientHostNames(config, client) {
+			data.names[name] = client
+		}
+		for _, alias := range client.Aliases {
+			data.names[fmt.Sprintf("%s.%s", alias, config.Local)] = client
+		}
+	}
+
+	for _, piHoleConfig := range config.PiHole {
+		pihole.ClearAuth()
+		pihole.SetTimeout(piHoleConfig.timeout())
+		hostRecords, cnameRecords, err := pihole.GetLocalDns(piHoleConfig.Url, piHoleConfig.Password)
+		check(err)
+		for _, record := range hostRecords {
+			fields := strings.Fields(record)
+			if len(fields) == 2 && !slices.Contains(data.hosts[fields[1]], fields[0]) {
+				data.hosts[fields[1]] = append(data.hosts[fields[1]], fields[0])
+			}
+		}
+		for _, record := range cnameRecords {
+			fields := strings.Split(record, ",")
+			if len(fields) >= 2 {
+				data.cnames[fields[0]] = fields[1]
+			}
+		}
+	}
+	fmt.Println()
+
+	issues := 0
+	checked := 0
+	for _, instance := range config.npmInstances() {
+		npm := nginxproxymanager.NewClient(instance.Url, instance.Username, instance.Password, &http.Client{Timeout: instance.timeout()})
+		hosts, err := npm.GetProxyHosts([]string{nginxproxymanager.ProxyHost, nginxproxymanager.StreamHost})
+		check(err)
+		for _, host := range hosts {
+			if host.ForwardHost == "" || !host.Enabled {
+				continue
+			}
+			checked++
+			label := host.Type + " " + strings.Join(host.DomainNames, ", ")
+			if host.Type == nginxproxymanager.StreamHost {
+				label = fmt.Sprintf("stream %d", host.ID)
+			}
+			problem, detail := data.check(host.ForwardHost)
+			status := "OK  "
+			if problem {
+				status = "WARN"
+				issues++
+			}
+			fmt.Printf("%s %s (%s) -> %s:%d: %s\n", status, label, instance.displayName(), host.ForwardHost, host.ForwardPort, detail)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d Upstreams Checked\n", checked)
+	fmt.Printf("%d Upstreams Need Attention\n", issues)
+	if issues != 0 {
+		return 1
+	}
+	return 0
+}
+
+// check reports whether an upstream has a problem, along with a description of what it points at
+func (d auditData) check(upstream string) (bool, string) {
+	upstream = strings.TrimSuffix(strings.ToLower(upstream), ".")
+	if ip, err := netip.ParseAddr(upstream); err == nil {
+		return d.checkIp(ip.String(), nil)
+	}
+
+	name := upstream
+	if _, ok := d.resolve(name); !ok && !strings.HasSuffix(name, "."+d.local) {
+		name = upstream + "." + d.local
+	}
+	expected, known := d.names[name]
+	addresses, ok := d.resolve(name)
+	if !ok {
+		if known {
+			return true, fmt.Sprintf("%s has no PiHole record yet, Unifi reserves %s for it", name, expected.Ip)
+		}
+		return true, fmt.Sprintf("unknown name, %s is not on the PiHoles or a Unifi fixed IP client", upstream)
+	}
+
+	var expectedClient *unificontroller.Client
+	if known {
+		expectedClient = &expected
+	}
+	var details []string
+	problem := false
+	for _, address := range addresses {
+		p, detail := d.checkIp(address, expectedClient)
+		problem = problem || p
+		details = append(details, detail)
+	}
+	return problem, strings.Join(details, "; ")
+}
+
+// checkIp describes what an address belongs to. When expected is set the address must be reserved for that device.
+func (d auditData) checkIp(ip string, expected *unificontroller.Client) (bool, string) {
+	if client, ok := d.fixed[ip]; ok {
+		if expected != nil && client.Mac != expected.Mac {
+			return true, fmt.Sprintf("%s is reserved for %s, not %s", ip, client.Name, expected.Name)
+		}
+		return false, fmt.Sprintf("%s is reserved for %s", ip, client.Name)
+	}
+	if client, ok := d.dynamic[ip]; ok {
+		return true, fmt.Sprintf("%s is a dynamic IP, last used by %s which has no reservation", ip, client.Name)
+	}
+	if expected != nil {
+		return true, fmt.Sprintf("%s is stale, Unifi now reserves %s for %s", ip, expected.Ip, expected.Name)
+	}
+	return true, fmt.Sprintf("%s does not belong to any Unifi client", ip)
+}
+
+// resolve follows the PiHole CNAMEs for name and returns the addresses it ends up at
+func (d auditData) resolve(name string) ([]string, bool) {
+	for i := 0; i < 10; i++ {
+		if addresses, ok := d.hosts[name]; ok {
+			return addresses, true
+		}
+		target, ok := d.cnames[name]
+		if !ok {
+			return nil, false
+		}
+		name = target
+	}
+	return nil, false
+}
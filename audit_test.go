@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unipidns/internal/unificontroller"
+)
+
+func TestAuditCheck(t *testing.T) {
+	nas := unificontroller.Client{Name: "nas.lan", Ip: "192.168.1.10", Mac: "aa:aa", Fixed: true}
+	printer := unificontroller.Client{Name: "printer.lan", Ip: "192.168.1.20", Mac: "bb:bb", Fixed: true}
+	laptop := unificontroller.Client{Name: "laptop.lan", Ip: "192.168.1.150", Mac: "cc:cc"}
+	data := auditData{
+		local:   "lan",
+		fixed:   map[string]unificontroller.Client{nas.Ip: nas, printer.Ip: printer},
+		dynamic: map[string]unificontroller.Client{laptop.Ip: laptop},
+		names:   map[string]unificontroller.Client{"nas.lan": nas, "printer.lan": printer, "camera.lan": {Name: "camera.lan", Ip: "192.168.1.30", Mac: "dd:dd", Fixed: true}},
+		hosts: map[string][]string{
+			"nas.lan":     {"192.168.1.10"},
+			"printer.lan": {"192.168.1.21"},
+			"old.lan":     {"192.168.1.20"},
+			"laptop.lan":  {"192.168.1.150"},
+		},
+		cnames: map[string]string{"files.lan": "nas.lan", "loop.lan": "loop.lan"},
+	}
+	tests := []struct {
+		upstream string
+		problem  bool
+		detail   string
+	}{
+		{"192.168.1.10", false, "192.168.1.10 is reserved for nas.lan"},
+		{"nas", false, "192.168.1.10 is reserved for nas.lan"},
+		{"NAS.lan.", false, "192.168.1.10 is reserved for nas.lan"},
+		{"files.lan", false, "192.168.1.10 is reserved for nas.lan"},
+		// the PiHole record still has the address from before the reservation moved
+		{"printer", true, "192.168.1.21 is stale, Unifi now reserves 192.168.1.20 for printer.lan"},
+		// a name that isn't a client only has to point at a reserved address
+		{"old.lan", false, "192.168.1.20 is reserved for printer.lan"},
+		{"laptop", true, "192.168.1.150 is a dynamic IP, last used by laptop.lan which has no reservation"},
+		{"192.168.1.99", true, "192.168.1.99 does not belong to any Unifi client"},
+		// reserved in Unifi but missing from the PiHoles
+		{"camera", true, "camera.lan has no PiHole record yet, Unifi reserves 192.168.1.30 for it"},
+		{"unknown.lan", true, "unknown name, unknown.lan is not on the PiHoles or a Unifi fixed IP client"},
+		{"loop.lan", true, "unknown name"},
+	}
+	for _, test := range tests {
+		problem, detail := data.check(test.upstream)
+		if problem != test.problem || !strings.HasPrefix(detail, test.detail) {
+			t.Errorf("Expected %s to give %t %q, got %t %q", test.upstream, test.problem, test.detail, problem, detail)
+		}
+	}
+}
+
+func TestAuditCheckIp(t *testing.T) {
+	nas := unificontroller.Client{Name: "nas.lan", Ip: "192.168.1.10", Mac: "aa:aa", Fixed: true}
+	printer := unificontroller.Client{Name: "printer.lan", Ip: "192.168.1.20", Mac: "bb:bb", Fixed: true}
+	data := auditData{fixed: map[string]unificontroller.Client{nas.Ip: nas, printer.Ip: printer}}
+	tests := []struct {
+		ip       string
+		expected *unificontroller.Client
+		problem  bool
+		detail   string
+	}{
+		{"192.168.1.10", &nas, false, "192.168.1.10 is reserved for nas.lan"},
+		{"192.168.1.20", &nas, true, "192.168.1.20 is reserved for printer.lan, not nas.lan"},
+		{"192.168.1.11", &nas, true, "192.168.1.11 is stale, Unifi now reserves 192.168.1.10 for nas.lan"},
+		{"192.168.1.11", nil, true, "192.168.1.11 does not belong to any Unifi client"},
+	}
+	for _, test := range tests {
+		problem, detail := data.checkIp(test.ip, test.expected)
+		if problem != test.problem || detail != test.detail {
+			t.Errorf("Expected %s to give %t %q, got %t %q", test.ip, test.problem, test.detail, problem, detail)
+		}
+	}
+}
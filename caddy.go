@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"unipidns/internal/caddy"
+)
+
+// addCaddyRecords adds the hosts matched by the HTTP routes of every Caddy instance to edges
+func addCaddyRecords(config *Config, edges *edgeRecords) error {
+	for _, instance := range config.Caddy {
+		name := instance.displayName()
+		fmt.Printf("Fetching config from Caddy %s\n", name)
+		client := caddy.NewClient(instance.Url, &http.Client{Timeout: instance.timeout()})
+		hosts, err := client.GetHosts()
+		if err != nil {
+			return err
+		}
+
+		instanceDomains := instance.Domains
+		if len(instanceDomains) == 0 {
+			instanceDomains = config.publicDomains()
+		}
+		defaultEdge := config.WebEdge
+		if instance.WebEdge != "" {
+			defaultEdge = instance.WebEdge
+		}
+
+		cnames := 0
+		for _, host := range hosts {
+			if edges.add(name, host, instanceDomains, defaultEdge, "") {
+				cnames++
+			}
+		}
+		fmt.Printf("%d CNAME Hosts From Caddy Routes\n", cnames)
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unipidns/internal/domains"
+	"unipidns/internal/httpclient"
+	"unipidns/internal/unificontroller"
+)
+
+type Config struct {
+	Unifi  *unificontroller.Config `json:"unifi"`
+	PiHole []PiHole                `json:"pihole"`
+	// DnsTargets lists further DNS servers to publish to, each picked by its type
+	DnsTargets        []DnsTarget        `json:"dnsTargets"`
+	NginxProxyManager *NginxProxyManager `json:"nginxProxyManager"`
+	// NginxProxyManagers lists further instances, each with its own domains and web edge
+	NginxProxyManagers []NginxProxyManager `json:"nginxProxyManagers"`
+	// Traefik lists Traefik instances whose routers are published like NPM hosts
+	Traefik []Traefik `json:"traefik"`
+	// Caddy lists Caddy instances whose site addresses are published like NPM hosts
+	Caddy []Caddy `json:"caddy"`
+	// Docker lists Docker hosts whose labelled containers get records
+	Docker []Docker `json:"docker"`
+	// Kubernetes lists clusters whose Ingress and HTTPRoute hosts are published
+	Kubernetes []Kubernetes     `json:"kubernetes"`
+	Domain     string           `json:"domain"`
+	Domains    []domains.Domain `json:"domains"`
+	WebEdge    string           `json:"webEdge"`
+	Local      string           `json:"local"`
+}
+
+// source holds the settings shared by every upstream and DNS target
+type source struct {
+	Name string `json:"name"`
+	Url  string `json:"url"`
+	// Timeout is the request timeout in seconds, 30 when not set
+	Timeout int `json:"timeout"`
+}
+
+func (s *source) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return time.Duration(s.Timeout) * time.Second
+	}
+	return httpclient.DefaultTimeout
+}
+
+// displayName is the name, or the url when there is none
+func (s *source) displayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Url
+}
+
+type PiHole struct {
+	source
+	Password string `json:"password"`
+}
+
+// DnsTarget is a DNS server of any supported type, each type uses the settings listed in targetSettings
+// and any other setting is rejected
+type DnsTarget struct {
+	source
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	// Ptr also publishes PTR records for A and AAAA records in the matching reverse zone
+	Ptr bool `json:"ptr"`
+	// Server is the DNS server as host or host:port
+	Server string `json:"server"`
+	// Zones are the zones records are published in, the local suffix when not set
+	Zones []string `json:"zones"`
+	// ReverseZones are the in-addr.arpa and ip6.arpa zones PTR records are published in
+	ReverseZones  []string `json:"reverseZones"`
+	TsigKey       string   `json:"tsigKey"`
+	TsigSecret    string   `json:"tsigSecret"`
+	TsigAlgorithm string   `json:"tsigAlgorithm"`
+	// Ttl is the TTL of the records in seconds, 3600 when not set
+	Ttl int `json:"ttl"`
+	// Directory is where files are written
+	Directory string `json:"directory"`
+	// Nameserver and Hostmaster go in the SOA record of a zone file
+	Nameserver string `json:"nameserver"`
+	Hostmaster string `json:"hostmaster"`
+	// NameserverAddress is the glue address of a nameserver inside one of the zones
+	NameserverAddress string `json:"nameserverAddress"`
+	// Path is the file a managed block is written to
+	Path string `json:"path"`
+	// LocalZoneType is the unbound local-zone type of the local suffix, transparent when not set
+	LocalZoneType string `json:"localZoneType"`
+	// Command is run whenever a file changes, such as a reload of the DNS server
+	Command string `json:"command"`
+
+	// settings are the keys present in the config, to check them against the type
+	settings []string
+}
+
+// targetSettings are the settings each type of DNS target uses, besides type, name and timeout
+var targetSettings = map[string][]string{
+	"pihole":     {"url", "password"},
+	"adguard":    {"url", "username", "password"},
+	"technitium": {"url", "token", "ptr"},
+	"rfc2136":    {"server", "zones", "reverseZones", "tsigKey", "tsigSecret", "tsigAlgorithm", "ttl"},
+	"zonefile":   {"directory", "zones", "reverseZones", "nameserver", "nameserverAddress", "hostmaster", "ttl", "command"},
+	"hosts":      {"path", "command"},
+	"dnsmasq":    {"path", "command"},
+	"unbound":    {"path", "localZoneType", "command"},
+}
+
+// UnmarshalJSON also records which settings were given
+func (t *DnsTarget) UnmarshalJSON(data []byte) error {
+	type plain DnsTarget
+	err := json.Unmarshal(data, (*plain)(t))
+	if err != nil {
+		return err
+	}
+	var settings map[string]json.RawMessage
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		return err
+	}
+	t.settings = slices.Sorted(maps.Keys(settings))
+	return nil
+}
+
+// validate rejects a setting the type doesn't use, which is usually a typo or a setting meant for
+// another target
+func (t *DnsTarget) validate() error {
+	allowed, ok := targetSettings[t.Type]
+	if !ok {
+		return fmt.Errorf("unknown DNS target type %q for %s", t.Type, t.displayName())
+	}
+	for _, setting := range t.settings {
+		if setting != "type" && setting != "name" && setting != "timeout" && !slices.Contains(allowed, setting) {
+			return fmt.Errorf("%s is not a setting for %s targets, %s can use %s", setting, t.Type, t.displayName(), strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// endpoint describes where the target is, which is a url, a server or a file depending on the type
+func (t *DnsTarget) endpoint() string {
+	switch t.Type {
+	case "rfc2136":
+		return "Server: " + t.Server
+	case "zonefile":
+		return "Directory: " + t.Directory
+	case "hosts", "dnsmasq", "unbound":
+		return "Path: " + t.Path
+	}
+	return "Url: " + t.Url
+}
+
+func (t *DnsTarget) ttl() uint32 {
+	if t.Ttl > 0 {
+		return uint32(t.Ttl)
+	}
+	return defaultTtl
+}
+
+type NginxProxyManager struct {
+	source
+	Username  string   `json:"username"`
+	Password  string   `json:"password"`
+	HostTypes []string `json:"hostTypes"`
+	// SkipOffline also skips hosts whose configuration nginx failed to load
+	SkipOffline bool `json:"skipOffline"`
+	// Domains limits this instance to these domains instead of the top level ones
+	Domains []domains.Domain `json:"domains"`
+	// WebEdge is the CNAME target for this instance instead of the top level one
+	WebEdge string `json:"webEdge"`
+	// SplitHorizon treats hosts behind an access list differently to public ones
+	SplitHorizon *SplitHorizon `json:"splitHorizon"`
+}
+
+type Traefik struct {
+	source
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Domains limits this instance to these domains instead of the top level ones
+	Domains []domains.Domain `json:"domains"`
+	// WebEdge is the CNAME target for this instance instead of the top level one
+	WebEdge string `json:"webEdge"`
+}
+
+// Caddy is read through its admin API, usually http://host:2019
+type Caddy struct {
+	source
+	// Domains limits this instance to these domains instead of the top level ones
+	Domains []domains.Domain `json:"domains"`
+	// WebEdge is the CNAME target for this instance instead of the top level one
+	WebEdge string `json:"webEdge"`
+}
+
+// Docker is reached at a url such as unix:///var/run/docker.sock or tcp://docker.lan:2375
+type Docker struct {
+	source
+	// CertPath is a directory holding ca.pem, cert.pem and key.pem for a TLS protected daemon
+	CertPath string `json:"certPath"`
+	// Host is the Unifi name of the machine running Docker, taken from the url when it is not set
+	Host string `json:"host"`
+}
+
+// Kubernetes clusters are reached through their kubeconfig, so they leave the url empty
+type Kubernetes struct {
+	source
+	// Kubeconfig is the kubeconfig file to use. When empty the service account is used inside a cluster,
+	// otherwise $KUBECONFIG or ~/.kube/config
+	Kubeconfig string `json:"kubeconfig"`
+	// Context picks a context from the kubeconfig instead of the current one
+	Context string `json:"context"`
+	// Ingress is the CNAME target for the hosts. When empty they get A records for the load balancer
+	// address in the Ingress or Gateway status instead
+	Ingress string `json:"ingress"`
+	// Domains limits this cluster to these domains instead of the top level ones
+	Domains []domains.Domain `json:"domains"`
+}
+
+func (k *Kubernetes) displayName() string {
+	if k.Name != "" {
+		return k.Name
+	}
+	if k.Context != "" {
+		return k.Context
+	}
+	return "cluster"
+}
+
+// skipHosts is the split horizon setting that publishes no records for a category of host
+const skipHosts = "skip"
+
+// SplitHorizon picks what each category of host points at. Internal hosts are proxy hosts with an
+// access list, public hosts are everything else. Each is either "skip", a web edge name, or empty
+// for the usual web edge.
+type SplitHorizon struct {
+	Internal string `json:"internal"`
+	Public   string `json:"public"`
+	// InspectAccessLists only counts a host as internal if its access list allows nothing but private
+	// ranges and has no basic auth users
+	InspectAccessLists bool `json:"inspectAccessLists"`
+}
+
+// publicDomains returns the top level domains, with the single domain setting first
+func (c *Config) publicDomains() []domains.Domain {
+	publicDomains := c.Domains
+	if c.Domain != "" {
+		publicDomains = append([]domains.Domain{{Name: c.Domain}}, publicDomains...)
+	}
+	return publicDomains
+}
+
+// allDomains returns every domain this tool publishes records for, across all instances
+func (c *Config) allDomains() []domains.Domain {
+	all := c.publicDomains()
+	for _, instance := range c.npmInstances() {
+		all = append(all, instance.Domains...)
+	}
+	for _, instance := range c.Traefik {
+		all = append(all, instance.Domains...)
+	}
+	for _, instance := range c.Caddy {
+		all = append(all, instance.Domains...)
+	}
+	for _, instance := range c.Kubernetes {
+		all = append(all, instance.Domains...)
+	}
+	return all
+}
+
+// npmInstances returns the single nginxProxyManager followed by any in nginxProxyManagers
+func (c *Config) npmInstances() []NginxProxyManager {
+	var instances []NginxProxyManager
+	if c.NginxProxyManager != nil {
+		instances = append(instances, *c.NginxProxyManager)
+	}
+	return append(instances, c.NginxProxyManagers...)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSource(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{
+		"traefik": [{"name": "edge", "url": "http://traefik.lan:8080", "timeout": 5}],
+		"caddy": [{"url": "http://caddy.lan:2019"}],
+		"kubernetes": [{"context": "homelab"}, {}]
+	}`), &config)
+	if err != nil {
+		t.Fatalf("Error unmarshalling config: %s", err)
+	}
+	tests := []struct {
+		name            string
+		expectedName    string
+		timeout         time.Duration
+		expectedTimeout time.Duration
+	}{
+		{config.Traefik[0].displayName(), "edge", config.Traefik[0].timeout(), 5 * time.Second},
+		{config.Caddy[0].displayName(), "http://caddy.lan:2019", config.Caddy[0].timeout(), 30 * time.Second},
+		{config.Kubernetes[0].displayName(), "homelab", config.Kubernetes[0].timeout(), 30 * time.Second},
+		{config.Kubernetes[1].displayName(), "cluster", config.Kubernetes[1].timeout(), 30 * time.Second},
+	}
+	for _, test := range tests {
+		if test.name != test.expectedName {
+			t.Errorf("Expected name %s, got %s", test.expectedName, test.name)
+		}
+		if test.timeout != test.expectedTimeout {
+			t.Errorf("Expected timeout %s for %s, got %s", test.expectedTimeout, test.name, test.timeout)
+		}
+	}
+}
+
+func TestDnsTargetValidation(t *testing.T) {
+	tests := []struct {
+		json     string
+		valid    bool
+		endpoint string
+	}{
+		{`{"type": "adguard", "name": "site-2", "url": "http://adguard.lan", "username": "admin", "password": "pass", "timeout": 10}`, true, "Url: http://adguard.lan"},
+		{`{"type": "rfc2136", "server": "ns1.lan", "zones": ["lan"], "tsigKey": "unipidns", "tsigSecret": "c2VjcmV0"}`, true, "Server: ns1.lan"},
+		{`{"type": "unbound", "path": "/etc/unbound/unipidns.conf", "command": "unbound-control reload"}`, true, "Path: /etc/unbound/unipidns.conf"},
+		// ptr is a technitium setting
+		{`{"type": "rfc2136", "server": "ns1.lan", "ptr": true}`, false, ""},
+		// a typo of path
+		{`{"type": "hosts", "pathh": "/etc/hosts"}`, false, ""},
+		{`{"type": "bind", "url": "http://bind.lan"}`, false, ""},
+	}
+	for _, test := range tests {
+		var target DnsTarget
+		err := json.Unmarshal([]byte(test.json), &target)
+		if err != nil {
+			t.Fatalf("Error unmarshalling %s: %s", test.json, err)
+		}
+		err = target.validate()
+		if (err == nil) != test.valid {
+			t.Errorf("Expected %s valid to be %t, got %v", test.json, test.valid, err)
+		}
+		if test.valid && target.endpoint() != test.endpoint {
+			t.Errorf("Expected endpoint %s, got %s", test.endpoint, target.endpoint())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+// PiHole cnameRecords cannot hold wildcards, so wildcard hosts are published as dnsmasq address lines
+// pointing at the addresses of the web edge instead.
+
+func isWildcard(domain string) bool {
+	return strings.HasPrefix(domain, "*.")
+}
+
+// wildcardRecords returns address records for a wildcard domain such as *.apps.awesome.com, one for
+// each address of target. hostRecords are "ip host" lines used to find the addresses.
+func wildcardRecords(domain string, target string, hostRecords []string) []records.Record {
+	var wildcards []records.Record
+	for _, line := range hostRecords {
+		record, ok := records.ParseHost(line)
+		if ok && record.Name == target {
+			wildcards = append(wildcards, records.Address(domain, record.Value))
+		}
+	}
+	return wildcards
+}
+
+// dnsmasqLine renders a wildcard record as an address line, which dnsmasq answers for
+// apps.awesome.com and everything below it
+func dnsmasqLine(record records.Record) string {
+	return fmt.Sprintf("address=/%s/%s", strings.TrimPrefix(record.Name, "*."), record.Value)
+}
+
+// parseDnsmasqLine reads an address line for a single domain back into the wildcard record it renders
+func parseDnsmasqLine(line string) (records.Record, bool) {
+	parts := strings.Split(strings.TrimPrefix(line, "address="), "/")
+	if !strings.HasPrefix(line, "address=/") || len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return records.Record{}, false
+	}
+	return records.Address("*."+parts[1], parts[2]), true
+}
+
+// ownsDnsmasqLine reports whether a dnsmasq line could have been written by this tool, which is an
+// address line for a single domain inside the configured domains. The address isn't checked against
+// the web edges, so a line left behind when an edge changes address is still removed. Every other
+// line, such as a block entry to 0.0.0.0 for a public domain, is left alone.
+func ownsDnsmasqLine(line string, publicDomains []domains.Domain) bool {
+	record, ok := parseDnsmasqLine(line)
+	if !ok {
+		return false
+	}
+	if addr, err := netip.ParseAddr(record.Value); err != nil || addr.IsUnspecified() {
+		return false
+	}
+	_, ok = domains.Match(publicDomains, strings.TrimPrefix(record.Name, "*."))
+	return ok
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+func TestIsWildcard(t *testing.T) {
+	tests := []struct {
+		domain   string
+		wildcard bool
+	}{
+		{"*.apps.awesome.com", true},
+		{"apps.awesome.com", false},
+		{"*apps.awesome.com", false},
+		{"files.*.awesome.com", false},
+	}
+	for _, test := range tests {
+		if isWildcard(test.domain) != test.wildcard {
+			t.Errorf("Expected isWildcard(%s) to be %t", test.domain, test.wildcard)
+		}
+	}
+}
+
+func TestWildcardRecords(t *testing.T) {
+	hostRecords := []string{"192.168.1.2 edge.lan", "fd00::2 edge.lan", "192.168.1.10 nas.lan", "not a record"}
+	tests := []struct {
+		target   string
+		expected []records.Record
+	}{
+		{"edge.lan", []records.Record{records.Address("*.apps.awesome.com", "192.168.1.2"), records.Address("*.apps.awesome.com", "fd00::2")}},
+		{"nas.lan", []records.Record{records.Address("*.apps.awesome.com", "192.168.1.10")}},
+		{"missing.lan", nil},
+	}
+	for _, test := range tests {
+		if wildcards := wildcardRecords("*.apps.awesome.com", test.target, hostRecords); !slices.Equal(wildcards, test.expected) {
+			t.Errorf("Expected %v for %s, got %v", test.expected, test.target, wildcards)
+		}
+	}
+}
+
+func TestDnsmasqLine(t *testing.T) {
+	tests := []struct {
+		record   records.Record
+		expected string
+	}{
+		{records.Address("*.apps.awesome.com", "192.168.1.2"), "address=/apps.awesome.com/192.168.1.2"},
+		{records.Address("*.apps.awesome.com", "fd00::2"), "address=/apps.awesome.com/fd00::2"},
+	}
+	for _, test := range tests {
+		if line := dnsmasqLine(test.record); line != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, line)
+		}
+	}
+}
+
+func TestOwnsDnsmasqLine(t *testing.T) {
+	publicDomains := []domains.Domain{{Name: "awesome.com"}}
+	tests := []struct {
+		line  string
+		owned bool
+	}{
+		{"address=/apps.awesome.com/192.168.1.2", true},
+		{"address=/apps.awesome.com/fd00::2", true},
+		{"address=/old.awesome.com/192.168.1.2", true},
+		// an address the web edge no longer has
+		{"address=/apps.awesome.com/192.168.1.99", true},
+		{"address=/ads.awesome.com/::", false},
+		{"address=/ads.awesome.com/0.0.0.0", false},
+		{"address=/ads.awesome.com/", false},
+		{"address=/apps.awesome.com/tracker.awesome.com/192.168.1.2", false},
+		{"address=/apps.other.net/192.168.1.2", false},
+		{"server=/awesome.com/192.168.1.2", false},
+		{"address=apps.awesome.com/192.168.1.2", false},
+	}
+	for _, test := range tests {
+		if owned := ownsDnsmasqLine(test.line, publicDomains); owned != test.owned {
+			t.Errorf("Expected %s owned to be %t", test.line, test.owned)
+		}
+	}
+}
+
+func TestParseDnsmasqLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		record records.Record
+		ok     bool
+	}{
+		{"address=/apps.awesome.com/192.168.1.2", records.Address("*.apps.awesome.com", "192.168.1.2"), true},
+		{"address=/apps.awesome.com/fd00::2", records.Address("*.apps.awesome.com", "fd00::2"), true},
+		{"address=/ads.awesome.com/", records.Record{}, false},
+		{"address=/apps.awesome.com/tracker.awesome.com/192.168.1.2", records.Record{}, false},
+		{"server=/awesome.com/192.168.1.2", records.Record{}, false},
+	}
+	for _, test := range tests {
+		record, ok := parseDnsmasqLine(test.line)
+		if ok != test.ok || record != test.record {
+			t.Errorf("Expected %v, %t for %s, got %v, %t", test.record, test.ok, test.line, record, ok)
+		}
+		if ok && dnsmasqLine(record) != test.line {
+			t.Errorf("Expected %s to render back to itself, got %s", test.line, dnsmasqLine(record))
+		}
+	}
+}
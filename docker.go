@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	urlProcessor "net/url"
+	"slices"
+	"strings"
+	"unipidns/internal/docker"
+)
+
+// dockerLabel holds the names for a container, several can be given separated by commas
+const dockerLabel = "unipidns.host"
+
+// buildDockerRecords returns host records ("ip name") and CNAMEs ("name,target") for the running
+// containers labelled with unipidns.host. Containers on macvlan or ipvlan networks get host records for
+// their own addresses, everything else is a CNAME to the Unifi name of the Docker host. Names without a
+// dot get the local suffix. Stopped containers are not listed, so their records are removed. Names
+// already claimed by a web edge source keep the edge record and the conflict is reported.
+func buildDockerRecords(config *Config, hostRecords []string, edges *edgeRecords) ([]string, []string, error) {
+	var hosts []string
+	var cnames []string
+	// names already published, a CNAME can't share a name with anything else
+	taken := map[string]bool{}
+	for _, record := range hostRecords {
+		if fields := strings.Fields(record); len(fields) == 2 {
+			taken[fields[1]] = true
+		}
+	}
+
+	for _, instance := range config.Docker {
+		name := instance.displayName()
+		fmt.Printf("Fetching containers from Docker %s\n", name)
+		dockerHost, err := instance.dockerHost()
+		if err != nil {
+			return nil, nil, err
+		}
+		target := fmt.Sprintf("%s.%s", dockerHost, config.Local)
+		if !taken[target] {
+			fmt.Printf("Docker host %s has no host record, its containers may not resolve\n", target)
+		}
+
+		client, err := docker.NewClient(instance.Url, instance.CertPath, instance.timeout())
+		if err != nil {
+			return nil, nil, err
+		}
+		containers, err := client.GetLabelledContainers(dockerLabel)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		added := 0
+		for _, container := range containers {
+			for _, label := range strings.Split(container.Label, ",") {
+				label = strings.ToLower(strings.TrimSpace(label))
+				if label == "" {
+					continue
+				}
+				if !strings.Contains(label, ".") {
+					label = fmt.Sprintf("%s.%s", label, config.Local)
+				}
+				source := fmt.Sprintf("Docker %s container %s", name, container.Name)
+				if len(container.Addresses) != 0 {
+					if !edges.claim(source, label, strings.Join(container.Addresses, " ")) {
+						continue
+					}
+					for _, address := range container.Addresses {
+						record := fmt.Sprintf("%s %s", address, label)
+						if !slices.Contains(hosts, record) {
+							hosts = append(hosts, record)
+						}
+					}
+					taken[label] = true
+					added++
+					continue
+				}
+				if taken[label] {
+					fmt.Printf("Skipping %s for container %s, the name is already in use\n", label, container.Name)
+					continue
+				}
+				if !edges.claim(source, label, target) {
+					continue
+				}
+				taken[label] = true
+				cnames = append(cnames, fmt.Sprintf("%s,%s", label, target))
+				added++
+			}
+		}
+		fmt.Printf("%d Labelled Containers Found\n", len(containers))
+		fmt.Printf("%d Records From Container Labels\n", added)
+	}
+	return hosts, cnames, nil
+}
+
+// dockerHost returns the configured host, or the first label of the url host for TCP daemons
+func (d *Docker) dockerHost() (string, error) {
+	if d.Host != "" {
+		return strings.ToLower(d.Host), nil
+	}
+	parsed, err := urlProcessor.Parse(d.Url)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Hostname() == "" || net.ParseIP(parsed.Hostname()) != nil {
+		return "", fmt.Errorf("docker %s needs host set to the Unifi name of the machine", d.displayName())
+	}
+	return strings.ToLower(strings.Split(parsed.Hostname(), ".")[0]), nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDockerRecordsSkipEdgeNames(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Error listening on %s: %s", socket, err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		switch r.URL.Path {
+		case "/networks":
+			w.Write([]byte(`[{"Id": "lan-id", "Name": "lan", "Driver": "macvlan"}]`))
+		case "/containers/json":
+			w.Write([]byte(`[
+				{"Id": "abc", "Names": ["/grafana"], "Labels": {"unipidns.host": "grafana.awesome.com,metrics"}},
+				{"Id": "def", "Names": ["/ha"], "Labels": {"unipidns.host": "ha.awesome.com,ha"},
+					"NetworkSettings": {"Networks": {"lan": {"NetworkID": "lan-id", "IPAddress": "192.168.1.50"}}}}
+			]`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	config := &Config{Local: "lan", Docker: []Docker{{source: source{Name: "test", Url: "unix://" + socket}, Host: "docker"}}}
+	hostRecords := []string{"192.168.1.5 docker.lan", "192.168.1.2 edge.lan"}
+	edges := newEdgeRecords(config, hostRecords)
+	edges.claim("Nginx Proxy Manager", "grafana.awesome.com", "edge.lan")
+	edges.cnameHosts = append(edges.cnameHosts, "grafana.awesome.com,edge.lan")
+	edges.claim("Traefik", "ha.awesome.com", "edge.lan")
+	edges.cnameHosts = append(edges.cnameHosts, "ha.awesome.com,edge.lan")
+
+	hosts, cnames, err := buildDockerRecords(config, hostRecords, edges)
+	if err != nil {
+		t.Fatalf("Error building records: %s", err)
+	}
+	if !slices.Equal(hosts, []string{"192.168.1.50 ha.lan"}) {
+		t.Errorf("Expected only the host record for ha.lan, got %v", hosts)
+	}
+	if !slices.Equal(cnames, []string{"metrics.lan,docker.lan"}) {
+		t.Errorf("Expected only the CNAME for metrics.lan, got %v", cnames)
+	}
+	if edges.conflicts != 2 {
+		t.Errorf("Expected 2 conflicts, got %d", edges.conflicts)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+// edgeClaim records which source asked for a domain and where it should point
+type edgeClaim struct {
+	source string
+	target string
+}
+
+// edgeRecords collects the CNAMEs ("domain,target"), host records ("ip domain") and wildcard address
+// records for the hosts served by web edges. When two sources claim the same domain with different
+// targets the first one wins and the conflict is reported.
+type edgeRecords struct {
+	local       string
+	hostRecords []string
+
+	cnameHosts []string
+	hosts      []string
+	wildcards  []records.Record
+	claims     map[string]edgeClaim
+	conflicts  int
+}
+
+func newEdgeRecords(config *Config, hostRecords []string) *edgeRecords {
+	return &edgeRecords{local: config.Local, hostRecords: hostRecords, claims: map[string]edgeClaim{}}
+}
+
+// buildEdgeRecords fetches the hosts from every web edge source. Nginx Proxy Manager is read first,
+// then Traefik, Caddy and Kubernetes.
+func buildEdgeRecords(config *Config, hostRecords []string) (*edgeRecords, error) {
+	edges := newEdgeRecords(config, hostRecords)
+	for _, addRecords := range []func(*Config, *edgeRecords) error{addNpmRecords, addTraefikRecords, addCaddyRecords, addKubernetesRecords} {
+		err := addRecords(config, edges)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if edges.conflicts != 0 {
+		fmt.Printf("%d Domain Conflicts Between Web Edge Sources\n", edges.conflicts)
+	}
+	return edges, nil
+}
+
+// add publishes domain if it is inside one of sourceDomains, pointing it at the web edge picked by the
+// matched domain, then edge, then the default. It returns true when a CNAME was added.
+func (e *edgeRecords) add(source string, domain string, sourceDomains []domains.Domain, defaultEdge string, edge string) bool {
+	matched, ok := domains.Match(sourceDomains, domain)
+	if !ok {
+		return false
+	}
+	webEdge := defaultEdge
+	if matched.WebEdge != "" {
+		webEdge = matched.WebEdge
+	}
+	if edge != "" {
+		webEdge = edge
+	}
+	target := fmt.Sprintf("%s.%s", webEdge, e.local)
+	if !e.claim(source, domain, target) {
+		return false
+	}
+	if isWildcard(domain) {
+		wildcards := wildcardRecords(domain, target, e.hostRecords)
+		if len(wildcards) == 0 {
+			fmt.Printf("Skipping wildcard %s, no address found for %s\n", domain, target)
+		}
+		e.addWildcards(wildcards)
+		return false
+	}
+	e.cnameHosts = append(e.cnameHosts, fmt.Sprintf("%s,%s", domain, target))
+	return true
+}
+
+// addAddresses publishes domain as host records for addresses if it is inside one of sourceDomains, for
+// sources that know the address of their load balancer rather than a web edge name. It returns true
+// when host records were added.
+func (e *edgeRecords) addAddresses(source string, domain string, sourceDomains []domains.Domain, addresses []string) bool {
+	if _, ok := domains.Match(sourceDomains, domain); !ok {
+		return false
+	}
+	if !e.claim(source, domain, strings.Join(addresses, " ")) {
+		return false
+	}
+
+	for _, address := range addresses {
+		if isWildcard(domain) {
+			e.addWildcards([]records.Record{records.Address(domain, address)})
+			continue
+		}
+		e.hosts = append(e.hosts, fmt.Sprintf("%s %s", address, domain))
+	}
+	return !isWildcard(domain)
+}
+
+// claim records that source wants domain pointing at target, returning false if it was already claimed
+func (e *edgeRecords) claim(source string, domain string, target string) bool {
+	if claim, claimed := e.claims[domain]; claimed {
+		if claim.target != target {
+			fmt.Printf("CONFLICT: %s is claimed by %s (-> %s) and %s (-> %s), keeping %s\n", domain, claim.source, claim.target, source, target, claim.source)
+			e.conflicts++
+		}
+		return false
+	}
+	e.claims[domain] = edgeClaim{source: source, target: target}
+	return true
+}
+
+func (e *edgeRecords) addWildcards(wildcards []records.Record) {
+	for _, wildcard := range wildcards {
+		if !slices.Contains(e.wildcards, wildcard) {
+			e.wildcards = append(e.wildcards, wildcard)
+		}
+	}
+}
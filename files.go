@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"unipidns/internal/managedfile"
+	"unipidns/internal/records"
+)
+
+// fileTarget writes the records into a managed block of a hosts file or dnsmasq config file
+type fileTarget struct {
+	name    string
+	path    string
+	command string
+	// render turns the desired records into the lines of the block
+	render func(desired *desiredState) []string
+	label  string
+}
+
+func newHostsTarget(target DnsTarget) (*fileTarget, error) {
+	if target.Path == "" {
+		return nil, errors.New("hosts targets need a path")
+	}
+	return &fileTarget{name: target.Name, path: target.Path, command: target.Command, render: hostsLines, label: "Hosts File"}, nil
+}
+
+func newDnsmasqTarget(target DnsTarget) (*fileTarget, error) {
+	if target.Path == "" {
+		return nil, errors.New("dnsmasq targets need a path")
+	}
+	return &fileTarget{name: target.Name, path: target.Path, command: target.Command, render: dnsmasqConfLines, label: "Dnsmasq Config"}, nil
+}
+
+func (f *fileTarget) displayName() string {
+	return f.label + ": " + f.name
+}
+
+// sync only owns the lines between the managed block markers, so the file can hold other entries too
+func (f *fileTarget) sync(desired *desiredState) error {
+	lines := f.render(desired)
+	content := strings.Join(lines, "\n")
+	changed, err := managedfile.UpdateBlock(f.path, []byte(content), desired.dryRun)
+	if err != nil {
+		return err
+	}
+	status := "unchanged"
+	if changed && desired.dryRun {
+		status = "would be updated"
+	} else if changed {
+		status = "updated"
+	}
+	fmt.Printf("	%s %s, %d Lines\n", f.path, status, len(lines))
+
+	if changed && f.command != "" && !desired.dryRun {
+		return runCommand(f.command)
+	}
+	return nil
+}
+
+// addressNames pairs an address with every name pointing at it
+type addressNames struct {
+	address string
+	names   []string
+}
+
+// groupByAddress groups address records by their value, sorted by address so the output only
+// changes when the records do. Names keep the order they were added in, so the client name comes first.
+func groupByAddress(addressRecords []records.Record) []addressNames {
+	var groups []addressNames
+	for _, record := range addressRecords {
+		idx := slices.IndexFunc(groups, func(g addressNames) bool { return g.address == record.Value })
+		if idx < 0 {
+			groups = append(groups, addressNames{address: record.Value})
+			idx = len(groups) - 1
+		}
+		if !slices.Contains(groups[idx].names, record.Name) {
+			groups[idx].names = append(groups[idx].names, record.Name)
+		}
+	}
+	slices.SortFunc(groups, func(a addressNames, b addressNames) int {
+		first, errA := netip.ParseAddr(a.address)
+		second, errB := netip.ParseAddr(b.address)
+		if errA != nil || errB != nil {
+			return cmp.Compare(a.address, b.address)
+		}
+		return first.Compare(second)
+	})
+	return groups
+}
+
+// hostsLines renders a hosts file with one line for each address. Hosts files can't hold CNAMEs so
+// they are flattened to the addresses they lead to, and wildcards are left out.
+func hostsLines(desired *desiredState) []string {
+	flattened, unresolved := desired.records.Flatten()
+	flattened = slices.DeleteFunc(flattened, records.Record.Wildcard)
+	if len(unresolved) != 0 {
+		fmt.Printf("	%d CNAMEs Skipped, their targets have no address here\n", len(unresolved))
+	}
+	var lines []string
+	for _, group := range groupByAddress(flattened) {
+		lines = append(lines, fmt.Sprintf("%s\t%s", group.address, strings.Join(group.names, " ")))
+	}
+	return lines
+}
+
+// dnsmasqConfLines renders host-record lines for each address, cname lines and address lines for the
+// wildcards. dnsmasq only answers a cname line when the target is one of its own names, so CNAMEs to
+// anything else are left out.
+func dnsmasqConfLines(desired *desiredState) []string {
+	var hosts []records.Record
+	for _, record := range desired.records.Records {
+		if (record.Type == records.A || record.Type == records.AAAA) && !record.Wildcard() {
+			hosts = append(hosts, record)
+		}
+	}
+	var lines []string
+	for _, group := range groupByAddress(hosts) {
+		lines = append(lines, fmt.Sprintf("host-record=%s,%s", strings.Join(group.names, ","), group.address))
+	}
+
+	_, unresolved := desired.records.Flatten()
+	if len(unresolved) != 0 {
+		fmt.Printf("	%d CNAMEs Skipped, their targets have no address here\n", len(unresolved))
+	}
+	var cnames []string
+	for _, record := range desired.records.Records {
+		if record.Type != records.CNAME || record.Wildcard() || slices.Contains(unresolved, record) {
+			continue
+		}
+		cnames = append(cnames, fmt.Sprintf("cname=%s,%s", record.Name, record.Value))
+	}
+	slices.Sort(cnames)
+	lines = append(lines, cnames...)
+
+	var wildcards []string
+	for _, wildcard := range desired.records.Wildcards() {
+		if wildcard.Type != records.CNAME {
+			wildcards = append(wildcards, dnsmasqLine(wildcard))
+		}
+	}
+	slices.Sort(wildcards)
+	return append(lines, slices.Compact(wildcards)...)
+}
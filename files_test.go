@@ -0,0 +1,46 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"unipidns/internal/records"
+)
+
+// testFileState has two names on one address, a CNAME to it, a CNAME that resolves nowhere and a wildcard
+func testFileState() *desiredState {
+	desired := &desiredState{local: "lan"}
+	desired.records.Add(
+		records.Address("nas.lan", "192.168.1.10"),
+		records.Address("storage.lan", "192.168.1.10"),
+		records.Address("nas.lan", "fd00::10"),
+		records.Address("edge.lan", "192.168.1.2"),
+		records.Cname("files.awesome.com", "nas.lan"),
+		records.Cname("remote.lan", "vpn.example.net"),
+		records.Address("*.apps.awesome.com", "192.168.1.2"),
+	)
+	return desired
+}
+
+func TestHostsLines(t *testing.T) {
+	expected := []string{
+		"192.168.1.2\tedge.lan",
+		"192.168.1.10\tnas.lan storage.lan files.awesome.com",
+		"fd00::10\tnas.lan files.awesome.com",
+	}
+	if lines := hostsLines(testFileState()); !slices.Equal(lines, expected) {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+}
+
+func TestDnsmasqConfLines(t *testing.T) {
+	expected := []string{
+		"host-record=edge.lan,192.168.1.2",
+		"host-record=nas.lan,storage.lan,192.168.1.10",
+		"host-record=nas.lan,fd00::10",
+		"cname=files.awesome.com,nas.lan",
+		"address=/apps.awesome.com/192.168.1.2",
+	}
+	if lines := dnsmasqConfLines(testFileState()); !slices.Equal(lines, expected) {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+}
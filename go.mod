@@ -2,8 +2,17 @@ module unipidns
 
 go 1.23.4
 
+require (
+	github.com/miekg/dns v1.1.59
+	github.com/unpoller/unifi v0.4.3
+	golang.org/x/net v0.24.0
+	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
 require (
 	github.com/brianvoe/gofakeit/v6 v6.28.0 // indirect
-	github.com/unpoller/unifi v0.4.3 // indirect
-	golang.org/x/net v0.24.0 // indirect
+	golang.org/x/mod v0.16.0 // indirect
+	golang.org/x/sys v0.19.0 // indirect
+	golang.org/x/tools v0.19.0 // indirect
 )
@@ -0,0 +1,90 @@
+package adguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unipidns/internal/httpclient"
+)
+
+// Client manages the DNS rewrites of an AdGuard Home instance
+type Client struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// Rewrite is a DNS rewrite. Answer is an IP address for an A or AAAA record, or a domain for a CNAME.
+// Domain may be a wildcard such as *.apps.awesome.com.
+type Rewrite struct {
+	Domain string `json:"domain"`
+	Answer string `json:"answer"`
+}
+
+// NewClient creates a client for the AdGuard Home at url.
+func NewClient(url string, username string, password string, httpClient *http.Client) *Client {
+	return &Client{
+		url:        strings.TrimRight(url, "/"),
+		username:   username,
+		password:   password,
+		httpClient: httpclient.OrDefault(httpClient),
+	}
+}
+
+// GetRewrites returns every DNS rewrite
+func (c *Client) GetRewrites() ([]Rewrite, error) {
+	var rewrites []Rewrite
+	err := c.do("GET", "/control/rewrite/list", nil, &rewrites)
+	if err != nil {
+		return nil, err
+	}
+	return rewrites, nil
+}
+
+func (c *Client) AddRewrite(rewrite Rewrite) error {
+	return c.do("POST", "/control/rewrite/add", &rewrite, nil)
+}
+
+func (c *Client) DeleteRewrite(rewrite Rewrite) error {
+	return c.do("POST", "/control/rewrite/delete", &rewrite, nil)
+}
+
+func (c *Client) do(verb string, path string, body *Rewrite, v interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(jsonBody)
+	}
+	req, err := http.NewRequest(verb, c.url+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("Failed to %s %s on AdGuard Home: %s %s", verb, path, res.Status, strings.TrimSpace(string(resBody)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(resBody, v)
+}
@@ -0,0 +1,73 @@
+package adguard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRewrites(t *testing.T) {
+	rewrites := []Rewrite{{Domain: "nas.lan", Answer: "192.168.1.10"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "admin" || password != "pass" {
+			t.Errorf("Expected basic auth, got %s %s", username, password)
+		}
+		var rewrite Rewrite
+		if r.Method == "POST" {
+			err := json.NewDecoder(r.Body).Decode(&rewrite)
+			if err != nil {
+				t.Errorf("Error decoding rewrite: %s", err)
+			}
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /control/rewrite/list":
+			responseJson, err := json.Marshal(rewrites)
+			if err != nil {
+				t.Errorf("Error marshalling response: %s", err)
+			}
+			w.Write(responseJson)
+			return
+		case "POST /control/rewrite/add":
+			rewrites = append(rewrites, rewrite)
+		case "POST /control/rewrite/delete":
+			rewrites = slices.DeleteFunc(rewrites, func(r Rewrite) bool { return r == rewrite })
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "admin", "pass", nil)
+
+	err := client.AddRewrite(Rewrite{Domain: "files.awesome.com", Answer: "nas.lan"})
+	if err != nil {
+		t.Errorf("Error adding rewrite: %s", err)
+	}
+	err = client.DeleteRewrite(Rewrite{Domain: "nas.lan", Answer: "192.168.1.10"})
+	if err != nil {
+		t.Errorf("Error deleting rewrite: %s", err)
+	}
+	got, err := client.GetRewrites()
+	if err != nil {
+		t.Fatalf("Error getting rewrites: %s", err)
+	}
+	if !slices.Equal(got, []Rewrite{{Domain: "files.awesome.com", Answer: "nas.lan"}}) {
+		t.Errorf("Expected only the added rewrite, got %v", got)
+	}
+}
+
+func TestRewriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte("rewrite already exists\n"))
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, "admin", "pass", nil).AddRewrite(Rewrite{Domain: "nas.lan", Answer: "192.168.1.10"})
+	if err == nil {
+		t.Errorf("Expected an error for a 400")
+	}
+}
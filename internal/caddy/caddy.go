@@ -0,0 +1,99 @@
+package caddy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unipidns/internal/httpclient"
+)
+
+// Client reads the running config from the Caddy admin API
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// config is the part of the Caddy JSON config that holds the HTTP servers
+type config struct {
+	Apps struct {
+		Http struct {
+			Servers map[string]server `json:"servers"`
+		} `json:"http"`
+	} `json:"apps"`
+}
+
+type server struct {
+	Routes []route `json:"routes"`
+}
+
+type route struct {
+	Match  []matcher `json:"match"`
+	Handle []handler `json:"handle"`
+}
+
+type matcher struct {
+	Host []string `json:"host"`
+}
+
+// handler is any route handler, only subroutes carry further routes
+type handler struct {
+	Handler string  `json:"handler"`
+	Routes  []route `json:"routes"`
+}
+
+// NewClient creates a client for the admin API at url, usually http://host:2019.
+func NewClient(url string, httpClient *http.Client) *Client {
+	return &Client{url: strings.TrimRight(url, "/"), httpClient: httpclient.OrDefault(httpClient)}
+}
+
+// GetHosts returns every hostname in the host matchers of the HTTP server routes, including routes
+// nested in subroutes, which is where the Caddyfile adapter puts site blocks. Hosts using placeholders
+// are skipped as their value is only known per request.
+func (c *Client) GetHosts() ([]string, error) {
+	res, err := c.httpClient.Get(c.url + "/config/")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("Failed to get config from Caddy: %s", res.Status)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Caddy answers null when it is running without a config
+	var content *config
+	err = json.Unmarshal(resBody, &content)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, name := range slices.Sorted(maps.Keys(content.Apps.Http.Servers)) {
+		hosts = routeHosts(content.Apps.Http.Servers[name].Routes, hosts)
+	}
+	return hosts, nil
+}
+
+func routeHosts(routes []route, hosts []string) []string {
+	for _, r := range routes {
+		for _, m := range r.Match {
+			for _, host := range m.Host {
+				host = strings.ToLower(host)
+				if strings.Contains(host, "{") || slices.Contains(hosts, host) {
+					continue
+				}
+				hosts = append(hosts, host)
+			}
+		}
+		for _, h := range r.Handle {
+			hosts = routeHosts(h.Routes, hosts)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,71 @@
+package caddy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// testConfig is trimmed down from what the Caddyfile adapter produces for two site blocks
+const testConfig = `{
+	"apps": {
+		"http": {
+			"servers": {
+				"srv0": {
+					"listen": [":443"],
+					"routes": [
+						{
+							"match": [{"host": ["Grafana.awesome.com", "*.apps.awesome.com"]}],
+							"handle": [{
+								"handler": "subroute",
+								"routes": [{
+									"match": [{"host": ["nested.awesome.com"], "path": ["/api/*"]}],
+									"handle": [{"handler": "reverse_proxy", "upstreams": [{"dial": "grafana.lan:3000"}]}]
+								}]
+							}],
+							"terminal": true
+						},
+						{
+							"match": [{"host": ["{env.SITE}"]}, {"host": ["grafana.awesome.com"]}],
+							"handle": [{"handler": "static_response", "body": "hi"}]
+						}
+					]
+				}
+			}
+		}
+	}
+}`
+
+func TestGetHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config/" {
+			t.Errorf("Expected path /config/, got %s", r.URL.Path)
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(testConfig))
+	}))
+	defer server.Close()
+
+	hosts, err := NewClient(server.URL, nil).GetHosts()
+	if err != nil {
+		t.Fatalf("Error getting hosts: %s", err)
+	}
+	want := []string{"grafana.awesome.com", "*.apps.awesome.com", "nested.awesome.com"}
+	if !slices.Equal(hosts, want) {
+		t.Errorf("Expected %v, got %v", want, hosts)
+	}
+}
+
+func TestGetHostsWithoutConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte("null\n"))
+	}))
+	defer server.Close()
+
+	hosts, err := NewClient(server.URL, nil).GetHosts()
+	if err != nil || len(hosts) != 0 {
+		t.Errorf("Expected no hosts and no error, got %v %v", hosts, err)
+	}
+}
@@ -0,0 +1,170 @@
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	urlProcessor "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Client talks to the Docker Engine API over a unix socket or TCP
+type Client struct {
+	base       string
+	httpClient *http.Client
+}
+
+// Container is a running container carrying the label asked for
+type Container struct {
+	ID   string
+	Name string
+	// Label is the value of the label
+	Label string
+	// Addresses are the container's own addresses on macvlan and ipvlan networks, which are reachable
+	// on the LAN directly rather than through the Docker host
+	Addresses []string
+}
+
+type listedContainer struct {
+	Id              string            `json:"Id"`
+	Names           []string          `json:"Names"`
+	Labels          map[string]string `json:"Labels"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			NetworkID         string `json:"NetworkID"`
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+type network struct {
+	Id     string `json:"Id"`
+	Name   string `json:"Name"`
+	Driver string `json:"Driver"`
+}
+
+// NewClient creates a client for host, which is a Docker host address such as
+// unix:///var/run/docker.sock, tcp://docker.lan:2375 or https://docker.lan:2376. certPath is a
+// directory holding ca.pem, cert.pem and key.pem, as used by DOCKER_CERT_PATH, and turns on TLS.
+func NewClient(host string, certPath string, timeout time.Duration) (*Client, error) {
+	parsed, err := urlProcessor.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{}
+	base := ""
+	switch parsed.Scheme {
+	case "unix":
+		socket := parsed.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		base = "http://docker"
+	case "tcp", "http":
+		base = "http://" + parsed.Host
+	case "https":
+		base = "https://" + parsed.Host
+	default:
+		return nil, fmt.Errorf("unsupported Docker host %s", host)
+	}
+
+	if certPath != "" {
+		tlsConfig, err := clientTLSConfig(certPath)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+		base = strings.Replace(base, "http://", "https://", 1)
+	}
+	return &Client{base: base, httpClient: &http.Client{Timeout: timeout, Transport: transport}}, nil
+}
+
+func clientTLSConfig(certPath string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem"))
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+	ca, err := os.ReadFile(filepath.Join(certPath, "ca.pem"))
+	if err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", filepath.Join(certPath, "ca.pem"))
+		}
+		tlsConfig.RootCAs = pool
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return tlsConfig, nil
+}
+
+// GetLabelledContainers returns the running containers that have label set
+func (c *Client) GetLabelledContainers(label string) ([]Container, error) {
+	var networks []network
+	err := c.get("/networks", &networks)
+	if err != nil {
+		return nil, err
+	}
+	directNetworks := map[string]bool{}
+	for _, n := range networks {
+		if n.Driver == "macvlan" || n.Driver == "ipvlan" {
+			directNetworks[n.Id] = true
+		}
+	}
+
+	filters, err := json.Marshal(map[string][]string{"label": {label}})
+	if err != nil {
+		return nil, err
+	}
+	var listed []listedContainer
+	err = c.get("/containers/json?filters="+urlProcessor.QueryEscape(string(filters)), &listed)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []Container
+	for _, l := range listed {
+		container := Container{ID: l.Id, Label: l.Labels[label]}
+		if len(l.Names) != 0 {
+			container.Name = strings.TrimPrefix(l.Names[0], "/")
+		}
+		for _, settings := range l.NetworkSettings.Networks {
+			if !directNetworks[settings.NetworkID] {
+				continue
+			}
+			for _, address := range []string{settings.IPAddress, settings.GlobalIPv6Address} {
+				if address != "" {
+					container.Addresses = append(container.Addresses, address)
+				}
+			}
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+func (c *Client) get(path string, v interface{}) error {
+	res, err := c.httpClient.Get(c.base + path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("Failed to get %s from Docker: %s", strings.Split(path, "?")[0], res.Status)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resBody, v)
+}
@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetLabelledContainers(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Error listening on %s: %s", socket, err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/networks":
+			response = []network{{Id: "bridge-id", Name: "bridge", Driver: "bridge"}, {Id: "lan-id", Name: "lan", Driver: "macvlan"}}
+		case "/containers/json":
+			if r.URL.Query().Get("filters") != `{"label":["unipidns.host"]}` {
+				t.Errorf("Expected a label filter, got %s", r.URL.Query().Get("filters"))
+			}
+			response = []map[string]interface{}{
+				{
+					"Id":     "abc",
+					"Names":  []string{"/grafana"},
+					"Labels": map[string]string{"unipidns.host": "grafana,metrics"},
+					"NetworkSettings": map[string]interface{}{"Networks": map[string]interface{}{
+						"bridge": map[string]string{"NetworkID": "bridge-id", "IPAddress": "172.17.0.2"},
+					}},
+				},
+				{
+					"Id":     "def",
+					"Names":  []string{"/homeassistant"},
+					"Labels": map[string]string{"unipidns.host": "ha"},
+					"NetworkSettings": map[string]interface{}{"Networks": map[string]interface{}{
+						"lan": map[string]string{"NetworkID": "lan-id", "IPAddress": "192.168.1.50", "GlobalIPv6Address": "fd00::50"},
+					}},
+				},
+			}
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		responseJson, err := json.Marshal(response)
+		if err != nil {
+			t.Errorf("Error marshalling response: %s", err)
+		}
+		w.WriteHeader(200)
+		w.Write(responseJson)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient("unix://"+socket, "", time.Second)
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	containers, err := client.GetLabelledContainers("unipidns.host")
+	if err != nil {
+		t.Fatalf("Error getting containers: %s", err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(containers))
+	}
+	if containers[0].Name != "grafana" || containers[0].Label != "grafana,metrics" || len(containers[0].Addresses) != 0 {
+		t.Errorf("Expected grafana on the bridge, got %v", containers[0])
+	}
+	if !slices.Equal(containers[1].Addresses, []string{"192.168.1.50", "fd00::50"}) {
+		t.Errorf("Expected macvlan addresses, got %v", containers[1].Addresses)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient("ssh://docker.lan", "", time.Second)
+	if err == nil {
+		t.Errorf("Expected an error for an ssh host")
+	}
+	client, err := NewClient("tcp://docker.lan:2375", "", time.Second)
+	if err != nil || client.base != "http://docker.lan:2375" {
+		t.Errorf("Expected http://docker.lan:2375, got %v %v", client, err)
+	}
+}
@@ -0,0 +1,53 @@
+package domains
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Domain is a public domain whose hosts get internal CNAMEs. WebEdge overrides the default web edge
+// for hosts in this domain.
+type Domain struct {
+	Name    string `json:"name"`
+	WebEdge string `json:"webEdge"`
+}
+
+// UnmarshalJSON accepts either a plain domain name or an object
+func (d *Domain) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		d.Name = s
+		d.WebEdge = ""
+		return nil
+	}
+
+	type Alias Domain
+	aux := &struct{ *Alias }{Alias: (*Alias)(d)}
+	return json.Unmarshal(data, aux)
+}
+
+// Match returns the domain that host belongs to. Matching is on whole labels, so "notawesome.com"
+// is not part of "awesome.com", and the most specific domain wins when more than one matches.
+func Match(domains []Domain, host string) (Domain, bool) {
+	host = normalise(host)
+	var best Domain
+	found := false
+	for _, domain := range domains {
+		name := normalise(domain.Name)
+		if name == "" {
+			continue
+		}
+		if host != name && !strings.HasSuffix(host, "."+name) {
+			continue
+		}
+		if !found || len(name) > len(normalise(best.Name)) {
+			best = domain
+			found = true
+		}
+	}
+	return best, found
+}
+
+func normalise(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
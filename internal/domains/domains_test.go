@@ -0,0 +1,43 @@
+package domains
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	domains := []Domain{{Name: "awesome.com"}, {Name: "apps.awesome.com", WebEdge: "apps-edge"}, {Name: "Other.net."}}
+	tests := []struct {
+		host  string
+		found bool
+		name  string
+	}{
+		{"files.awesome.com", true, "awesome.com"},
+		{"awesome.com", true, "awesome.com"},
+		{"FILES.Awesome.com", true, "awesome.com"},
+		{"notawesome.com", false, ""},
+		{"awesome.com.evil.org", false, ""},
+		{"grafana.apps.awesome.com", true, "apps.awesome.com"},
+		{"www.other.net", true, "Other.net."},
+	}
+	for _, test := range tests {
+		domain, found := Match(domains, test.host)
+		if found != test.found || domain.Name != test.name {
+			t.Errorf("Match(%s) = %s, %t, expected %s, %t", test.host, domain.Name, found, test.name, test.found)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var domains []Domain
+	err := json.Unmarshal([]byte(`["awesome.com", {"name": "other.net", "webEdge": "edge-2"}]`), &domains)
+	if err != nil {
+		t.Fatalf("Error unmarshalling domains: %s", err)
+	}
+	if domains[0].Name != "awesome.com" || domains[0].WebEdge != "" {
+		t.Errorf("Expected plain awesome.com, got %v", domains[0])
+	}
+	if domains[1].Name != "other.net" || domains[1].WebEdge != "edge-2" {
+		t.Errorf("Expected other.net with edge-2, got %v", domains[1])
+	}
+}
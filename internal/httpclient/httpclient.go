@@ -0,0 +1,18 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is the request timeout used when none is configured
+const DefaultTimeout = 30 * time.Second
+
+// OrDefault returns client, or a client with DefaultTimeout when it is nil. The API clients take an
+// optional *http.Client so tests and callers can supply their own.
+func OrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: DefaultTimeout}
+	}
+	return client
+}
@@ -0,0 +1,210 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the service account files mounted into every pod
+const (
+	serviceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCa    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// restConfig is what is needed to reach the API server
+type restConfig struct {
+	server    string
+	token     string
+	username  string
+	password  string
+	tlsConfig *tls.Config
+}
+
+// kubeconfig is the part of a kubeconfig file used here. Exec and auth provider plugins are not supported.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string                 `yaml:"token"`
+			TokenFile             string                 `yaml:"tokenFile"`
+			ClientCertificate     string                 `yaml:"client-certificate"`
+			ClientCertificateData string                 `yaml:"client-certificate-data"`
+			ClientKey             string                 `yaml:"client-key"`
+			ClientKeyData         string                 `yaml:"client-key-data"`
+			Username              string                 `yaml:"username"`
+			Password              string                 `yaml:"password"`
+			Exec                  map[string]interface{} `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// loadConfig reads the kubeconfig at path using context, or the current context when it is empty.
+// With no path the service account of the pod is used when running in a cluster, otherwise
+// $KUBECONFIG or ~/.kube/config.
+func loadConfig(path string, context string) (*restConfig, error) {
+	if path == "" {
+		if host := os.Getenv("KUBERNETES_SERVICE_HOST"); host != "" {
+			return inClusterConfig(host, os.Getenv("KUBERNETES_SERVICE_PORT"))
+		}
+		path = os.Getenv("KUBECONFIG")
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(home, ".kube", "config")
+		}
+		// only the first file of a KUBECONFIG list is read
+		path = filepath.SplitList(path)[0]
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file kubeconfig
+	err = yaml.Unmarshal(raw, &file)
+	if err != nil {
+		return nil, err
+	}
+	// relative paths in a kubeconfig are relative to the file
+	base := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(base, p)
+	}
+
+	if context == "" {
+		context = file.CurrentContext
+	}
+	clusterName, userName := "", ""
+	for _, c := range file.Contexts {
+		if c.Name == context {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("context %q not found in %s", context, path)
+	}
+
+	config := &restConfig{tlsConfig: &tls.Config{}}
+	found := false
+	for _, c := range file.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		config.server = strings.TrimRight(c.Cluster.Server, "/")
+		config.tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := pemData(c.Cluster.CertificateAuthorityData, resolve(c.Cluster.CertificateAuthority))
+		if err != nil {
+			return nil, err
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("no certificates found for cluster %s", clusterName)
+			}
+			config.tlsConfig.RootCAs = pool
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("cluster %q not found in %s", clusterName, path)
+	}
+
+	for _, u := range file.Users {
+		if u.Name != userName {
+			continue
+		}
+		if u.User.Exec != nil {
+			return nil, fmt.Errorf("user %s uses an exec plugin, which is not supported, use a token or client certificate", userName)
+		}
+		config.token = u.User.Token
+		if u.User.TokenFile != "" {
+			token, err := os.ReadFile(resolve(u.User.TokenFile))
+			if err != nil {
+				return nil, err
+			}
+			config.token = strings.TrimSpace(string(token))
+		}
+		config.username, config.password = u.User.Username, u.User.Password
+		cert, err := pemData(u.User.ClientCertificateData, resolve(u.User.ClientCertificate))
+		if err != nil {
+			return nil, err
+		}
+		key, err := pemData(u.User.ClientKeyData, resolve(u.User.ClientKey))
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, err
+			}
+			config.tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+	return config, nil
+}
+
+func inClusterConfig(host string, port string) (*restConfig, error) {
+	token, err := os.ReadFile(serviceAccountToken)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(serviceAccountCa)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificates found in the service account CA")
+	}
+	if port == "" {
+		port = "443"
+	}
+	return &restConfig{
+		server:    "https://" + net.JoinHostPort(host, port),
+		token:     strings.TrimSpace(string(token)),
+		tlsConfig: &tls.Config{RootCAs: pool},
+	}, nil
+}
+
+// pemData returns the base64 data when it is set, otherwise the contents of file
+func pemData(data string, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
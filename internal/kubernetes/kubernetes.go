@@ -0,0 +1,208 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ingressPath   = "/apis/networking.k8s.io/v1/ingresses"
+	httpRoutePath = "/apis/gateway.networking.k8s.io/v1/httproutes"
+	gatewayPath   = "/apis/gateway.networking.k8s.io/v1/gateways"
+)
+
+// errNotFound is returned by get for a 404, which for a list means the resource type is not installed
+var errNotFound = errors.New("not found")
+
+// Client reads Ingress and Gateway API objects from the Kubernetes API
+type Client struct {
+	config     *restConfig
+	httpClient *http.Client
+}
+
+// Route is an Ingress or HTTPRoute with the hostnames it serves and the load balancer addresses
+// published in the status of the Ingress or of the Gateways the route is attached to
+type Route struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Hosts     []string
+	Addresses []string
+}
+
+type metadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type ingressList struct {
+	Items []struct {
+		Metadata metadata `json:"metadata"`
+		Spec     struct {
+			Rules []struct {
+				Host string `json:"host"`
+			} `json:"rules"`
+		} `json:"spec"`
+		Status struct {
+			LoadBalancer struct {
+				Ingress []struct {
+					IP string `json:"ip"`
+				} `json:"ingress"`
+			} `json:"loadBalancer"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type httpRouteList struct {
+	Items []struct {
+		Metadata metadata `json:"metadata"`
+		Spec     struct {
+			Hostnames  []string `json:"hostnames"`
+			ParentRefs []struct {
+				Kind      string `json:"kind"`
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"parentRefs"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+type gatewayList struct {
+	Items []struct {
+		Metadata metadata `json:"metadata"`
+		Status   struct {
+			Addresses []struct {
+				Type  string `json:"type"`
+				Value string `json:"value"`
+			} `json:"addresses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// NewClient connects using the kubeconfig at path, see loadConfig for how an empty path is handled
+func NewClient(kubeconfigPath string, context string, timeout time.Duration) (*Client, error) {
+	config, err := loadConfig(kubeconfigPath, context)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		config: config,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: config.tlsConfig},
+		},
+	}, nil
+}
+
+// GetRoutes returns every Ingress followed by every HTTPRoute, across all namespaces. Clusters
+// without the Gateway API installed just have no HTTPRoutes.
+func (c *Client) GetRoutes() ([]Route, error) {
+	var ingresses ingressList
+	err := c.get(ingressPath, &ingresses)
+	if err != nil {
+		return nil, err
+	}
+	var routes []Route
+	for _, ingress := range ingresses.Items {
+		route := Route{Kind: "Ingress", Namespace: ingress.Metadata.Namespace, Name: ingress.Metadata.Name}
+		for _, rule := range ingress.Spec.Rules {
+			route.Hosts = appendHost(route.Hosts, rule.Host)
+		}
+		for _, status := range ingress.Status.LoadBalancer.Ingress {
+			if status.IP != "" {
+				route.Addresses = append(route.Addresses, status.IP)
+			}
+		}
+		routes = append(routes, route)
+	}
+
+	var httpRoutes httpRouteList
+	err = c.get(httpRoutePath, &httpRoutes)
+	if errors.Is(err, errNotFound) {
+		return routes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var gateways gatewayList
+	err = c.get(gatewayPath, &gateways)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, err
+	}
+	gatewayAddresses := map[string][]string{}
+	for _, gateway := range gateways.Items {
+		key := gateway.Metadata.Namespace + "/" + gateway.Metadata.Name
+		for _, address := range gateway.Status.Addresses {
+			if address.Type == "" || address.Type == "IPAddress" {
+				gatewayAddresses[key] = append(gatewayAddresses[key], address.Value)
+			}
+		}
+	}
+
+	for _, httpRoute := range httpRoutes.Items {
+		route := Route{Kind: "HTTPRoute", Namespace: httpRoute.Metadata.Namespace, Name: httpRoute.Metadata.Name}
+		for _, hostname := range httpRoute.Spec.Hostnames {
+			route.Hosts = appendHost(route.Hosts, hostname)
+		}
+		for _, parent := range httpRoute.Spec.ParentRefs {
+			if parent.Kind != "" && parent.Kind != "Gateway" {
+				continue
+			}
+			namespace := parent.Namespace
+			if namespace == "" {
+				namespace = route.Namespace
+			}
+			for _, address := range gatewayAddresses[namespace+"/"+parent.Name] {
+				if !slices.Contains(route.Addresses, address) {
+					route.Addresses = append(route.Addresses, address)
+				}
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func appendHost(hosts []string, host string) []string {
+	host = strings.ToLower(host)
+	if host == "" || slices.Contains(hosts, host) {
+		return hosts
+	}
+	return append(hosts, host)
+}
+
+func (c *Client) get(path string, v interface{}) error {
+	req, err := http.NewRequest("GET", c.config.server+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	if c.config.token != "" {
+		req.Header.Add("Authorization", "Bearer "+c.config.token)
+	} else if c.config.username != "" {
+		req.SetBasicAuth(c.config.username, c.config.password)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 404 {
+		return fmt.Errorf("Failed to get %s from Kubernetes: %w", path, errNotFound)
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("Failed to get %s from Kubernetes: %s", path, res.Status)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resBody, v)
+}
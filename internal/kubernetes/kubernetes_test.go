@@ -0,0 +1,144 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const testIngresses = `{"items": [
+	{
+		"metadata": {"name": "grafana", "namespace": "monitoring"},
+		"spec": {"rules": [{"host": "Grafana.awesome.com"}, {"host": "grafana.awesome.com"}, {}]},
+		"status": {"loadBalancer": {"ingress": [{"ip": "192.168.1.240"}]}}
+	}
+]}`
+
+const testHTTPRoutes = `{"items": [
+	{
+		"metadata": {"name": "wiki", "namespace": "apps"},
+		"spec": {"hostnames": ["wiki.awesome.com"], "parentRefs": [{"name": "edge", "namespace": "gateway"}]}
+	},
+	{
+		"metadata": {"name": "local", "namespace": "gateway"},
+		"spec": {"hostnames": ["*.apps.awesome.com"], "parentRefs": [{"name": "edge"}]}
+	}
+]}`
+
+const testGateways = `{"items": [
+	{
+		"metadata": {"name": "edge", "namespace": "gateway"},
+		"status": {"addresses": [{"type": "IPAddress", "value": "192.168.1.241"}, {"type": "Hostname", "value": "edge.lan"}]}
+	}
+]}`
+
+// writeKubeconfig writes a kubeconfig for server using a token and returns its path
+func writeKubeconfig(t *testing.T, server *httptest.Server) string {
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: lab
+clusters:
+- name: lab
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: unipidns
+  user:
+    token: secret
+contexts:
+- name: lab
+  context:
+    cluster: lab
+    user: unipidns
+`, server.URL, base64.StdEncoding.EncodeToString(ca))
+	path := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(path, []byte(kubeconfig), 0600)
+	if err != nil {
+		t.Fatalf("Error writing kubeconfig: %s", err)
+	}
+	return path
+}
+
+func TestGetRoutes(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %s", r.Header.Get("Authorization"))
+		}
+		response := ""
+		switch r.URL.Path {
+		case ingressPath:
+			response = testIngresses
+		case httpRoutePath:
+			response = testHTTPRoutes
+		case gatewayPath:
+			response = testGateways
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(writeKubeconfig(t, server), "", time.Second)
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	routes, err := client.GetRoutes()
+	if err != nil {
+		t.Fatalf("Error getting routes: %s", err)
+	}
+	if len(routes) != 3 {
+		t.Fatalf("Expected 3 routes, got %d", len(routes))
+	}
+	if !slices.Equal(routes[0].Hosts, []string{"grafana.awesome.com"}) || !slices.Equal(routes[0].Addresses, []string{"192.168.1.240"}) {
+		t.Errorf("Expected grafana ingress on 192.168.1.240, got %v", routes[0])
+	}
+	for _, route := range routes[1:] {
+		if route.Kind != "HTTPRoute" || !slices.Equal(route.Addresses, []string{"192.168.1.241"}) {
+			t.Errorf("Expected HTTPRoute on the gateway address, got %v", route)
+		}
+	}
+}
+
+func TestGetRoutesWithoutGatewayApi(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ingressPath {
+			w.WriteHeader(404)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(testIngresses))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(writeKubeconfig(t, server), "lab", time.Second)
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	routes, err := client.GetRoutes()
+	if err != nil {
+		t.Fatalf("Error getting routes: %s", err)
+	}
+	if len(routes) != 1 {
+		t.Errorf("Expected only the ingress, got %v", routes)
+	}
+}
+
+func TestUnknownContext(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	_, err := NewClient(writeKubeconfig(t, server), "missing", time.Second)
+	if err == nil {
+		t.Errorf("Expected an error for a missing context")
+	}
+}
@@ -0,0 +1,106 @@
+package managedfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+// The markers around the part of a file this tool owns
+const (
+	BeginMarker = "# BEGIN unipidns managed block, changes made here will be overwritten"
+	EndMarker   = "# END unipidns managed block"
+)
+
+// UpdateBlock replaces the managed block in the file at path with content, leaving the rest of the file
+// alone. The block is appended when the file has none, and the file is created when it does not exist.
+// The file is only written when the block has changed, and with dryRun set never. It returns whether
+// the file changed.
+func UpdateBlock(path string, content []byte, dryRun bool) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	var block bytes.Buffer
+	block.WriteString(BeginMarker + "\n")
+	block.Write(content)
+	if len(content) != 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		block.WriteString("\n")
+	}
+	block.WriteString(EndMarker + "\n")
+
+	var updated []byte
+	begin := bytes.Index(existing, []byte(BeginMarker))
+	end := -1
+	if begin >= 0 {
+		// a stray end marker before the block must not hide the real one
+		end = bytes.Index(existing[begin:], []byte(EndMarker))
+	}
+	if end >= 0 {
+		end += begin + len(EndMarker)
+		if end < len(existing) && existing[end] == '\n' {
+			end++
+		}
+		updated = append(append(append([]byte{}, existing[:begin]...), block.Bytes()...), existing[end:]...)
+	} else {
+		updated = append([]byte{}, existing...)
+		if len(updated) != 0 && !bytes.HasSuffix(updated, []byte("\n")) {
+			updated = append(updated, '\n')
+		}
+		updated = append(updated, block.Bytes()...)
+	}
+
+	if bytes.Equal(updated, existing) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, Replace(path, updated)
+}
+
+// Update writes content to the file at path when it differs from what is there, for files that belong
+// entirely to this tool. With dryRun set it never writes. It returns whether the file changed.
+func Update(path string, content []byte, dryRun bool) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, Replace(path, content)
+}
+
+// Replace writes content to a temporary file next to path and renames it over path, so anything reading
+// the file never sees half of it. The mode of an existing file is kept.
+func Replace(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(content)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(temp.Name(), mode)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
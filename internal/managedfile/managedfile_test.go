@@ -0,0 +1,93 @@
+package managedfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	err := os.WriteFile(path, []byte("127.0.0.1\tlocalhost"), 0600)
+	if err != nil {
+		t.Fatalf("Error writing file: %s", err)
+	}
+
+	changed, err := UpdateBlock(path, []byte("192.168.1.10\tnas.lan"), false)
+	if err != nil || !changed {
+		t.Fatalf("Expected the block to be added, got %t %v", changed, err)
+	}
+	want := "127.0.0.1\tlocalhost\n" + BeginMarker + "\n192.168.1.10\tnas.lan\n" + EndMarker + "\n"
+	content, _ := os.ReadFile(path)
+	if string(content) != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, content)
+	}
+
+	changed, err = UpdateBlock(path, []byte("192.168.1.10\tnas.lan\n"), false)
+	if err != nil || changed {
+		t.Errorf("Expected no change for the same block, got %t %v", changed, err)
+	}
+
+	// lines added by hand after the block are kept
+	err = os.WriteFile(path, append(content, []byte("10.0.0.1\tvpn\n")...), 0600)
+	if err != nil {
+		t.Fatalf("Error writing file: %s", err)
+	}
+	changed, err = UpdateBlock(path, []byte("192.168.1.11\tnas.lan\n"), false)
+	if err != nil || !changed {
+		t.Fatalf("Expected the block to change, got %t %v", changed, err)
+	}
+	want = "127.0.0.1\tlocalhost\n" + BeginMarker + "\n192.168.1.11\tnas.lan\n" + EndMarker + "\n10.0.0.1\tvpn\n"
+	content, _ = os.ReadFile(path)
+	if string(content) != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, content)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode to be kept, got %s", info.Mode())
+	}
+}
+
+func TestUpdateBlockStrayEndMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	existing := EndMarker + "\n127.0.0.1\tlocalhost\n" + BeginMarker + "\n192.168.1.10\tnas.lan\n" + EndMarker + "\n"
+	err := os.WriteFile(path, []byte(existing), 0644)
+	if err != nil {
+		t.Fatalf("Error writing file: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = UpdateBlock(path, []byte("192.168.1.11\tnas.lan\n"), false)
+		if err != nil {
+			t.Fatalf("Error updating block: %s", err)
+		}
+	}
+	want := EndMarker + "\n127.0.0.1\tlocalhost\n" + BeginMarker + "\n192.168.1.11\tnas.lan\n" + EndMarker + "\n"
+	content, _ := os.ReadFile(path)
+	if string(content) != want {
+		t.Errorf("Expected the existing block to be replaced in place\n%s\ngot\n%s", want, content)
+	}
+}
+
+func TestUpdateBlockDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnsmasq.conf")
+	changed, err := UpdateBlock(path, []byte("cname=files.lan,nas.lan\n"), true)
+	if err != nil || !changed {
+		t.Errorf("Expected a dry run to report a change, got %t %v", changed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected a dry run not to create the file")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unipidns.conf")
+	changed, err := Update(path, []byte("server:\n"), false)
+	if err != nil || !changed {
+		t.Fatalf("Expected the file to be created, got %t %v", changed, err)
+	}
+	changed, err = Update(path, []byte("server:\n"), false)
+	if err != nil || changed {
+		t.Errorf("Expected no change for the same content, got %t %v", changed, err)
+	}
+}
@@ -0,0 +1,71 @@
+package nginxproxymanager
+
+import (
+	"net/netip"
+	"strings"
+)
+
+type AccessList struct {
+	ID         int                `json:"id"`
+	Name       string             `json:"name"`
+	SatisfyAny bool               `json:"satisfy_any"`
+	PassAuth   bool               `json:"pass_auth"`
+	Clients    []AccessListClient `json:"clients"`
+	Items      []AccessListItem   `json:"items"`
+}
+
+// AccessListClient is an allow or deny rule for an address or range
+type AccessListClient struct {
+	Address   string `json:"address"`
+	Directive string `json:"directive"`
+}
+
+// AccessListItem is a basic auth user
+type AccessListItem struct {
+	Username string `json:"username"`
+}
+
+// InternalOnly reports whether the list only lets in clients from private address ranges. Lists with
+// basic auth users are not internal only, as they are usually there so the host can be used from outside
+// with a password.
+func (a AccessList) InternalOnly() bool {
+	if len(a.Items) != 0 {
+		return false
+	}
+	allowed := 0
+	for _, client := range a.Clients {
+		if !strings.EqualFold(client.Directive, "allow") {
+			continue
+		}
+		if client.Address == "all" || !isPrivate(client.Address) {
+			return false
+		}
+		allowed++
+	}
+	return allowed != 0
+}
+
+func isPrivate(address string) bool {
+	prefix, err := netip.ParsePrefix(address)
+	if err != nil {
+		ip, err := netip.ParseAddr(address)
+		if err != nil {
+			return false
+		}
+		prefix = netip.PrefixFrom(ip, ip.BitLen())
+	}
+	ip := prefix.Masked().Addr()
+	// a range is only private if it fits inside one of the private ranges
+	return (ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()) && prefix.Bits() >= privateBits(ip)
+}
+
+// privateBits is the prefix length of the private range containing ip
+func privateBits(ip netip.Addr) int {
+	for _, private := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.0/8", "169.254.0.0/16", "fc00::/7", "fe80::/10", "::1/128"} {
+		prefix := netip.MustParsePrefix(private)
+		if prefix.Contains(ip) {
+			return prefix.Bits()
+		}
+	}
+	return ip.BitLen()
+}
@@ -0,0 +1,24 @@
+package nginxproxymanager
+
+import "testing"
+
+func TestInternalOnly(t *testing.T) {
+	tests := []struct {
+		description string
+		list        AccessList
+		want        bool
+	}{
+		{"private ranges", AccessList{Clients: []AccessListClient{{"10.0.0.0/8", "allow"}, {"fd00::/8", "allow"}, {"all", "deny"}}}, true},
+		{"single private address", AccessList{Clients: []AccessListClient{{"192.168.1.10", "allow"}}}, true},
+		{"public address allowed", AccessList{Clients: []AccessListClient{{"10.0.0.0/8", "allow"}, {"203.0.113.7", "allow"}}}, false},
+		{"range wider than private", AccessList{Clients: []AccessListClient{{"10.0.0.0/7", "allow"}}}, false},
+		{"allow all", AccessList{Clients: []AccessListClient{{"all", "allow"}}}, false},
+		{"basic auth", AccessList{Clients: []AccessListClient{{"10.0.0.0/8", "allow"}}, Items: []AccessListItem{{"chris"}}}, false},
+		{"no allow rules", AccessList{Clients: []AccessListClient{{"all", "deny"}}}, false},
+	}
+	for _, test := range tests {
+		if got := test.list.InternalOnly(); got != test.want {
+			t.Errorf("%s: expected %t, got %t", test.description, test.want, got)
+		}
+	}
+}
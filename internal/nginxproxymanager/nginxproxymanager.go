@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
+	"unipidns/internal/httpclient"
 )
 
 type AuthResponse struct {
@@ -20,125 +23,294 @@ type AuthRequest struct {
 	Secret   string `json:"secret"`
 }
 
-type Meta struct {
-	LetsencryptAgree bool    `json:"letsencrypt_agree"`
-	DNSChallenge     bool    `json:"dns_challenge"`
-	NginxOnline      bool    `json:"nginx_online"`
-	NginxErr         *string `json:"nginx_err"`
-}
-
-type Config struct {
-	ID                    int       `json:"id"`
-	CreatedOn             time.Time `json:"created_on"`
-	ModifiedOn            time.Time `json:"modified_on"`
-	OwnerUserID           int       `json:"owner_user_id"`
-	DomainNames           []string  `json:"domain_names"`
-	ForwardHost           string    `json:"forward_host"`
-	ForwardPort           int       `json:"forward_port"`
-	AccessListID          int       `json:"access_list_id"`
-	CertificateID         int       `json:"certificate_id"`
-	SSLForced             bool      `json:"ssl_forced"`
-	CachingEnabled        bool      `json:"caching_enabled"`
-	BlockExploits         bool      `json:"block_exploits"`
-	AdvancedConfig        string    `json:"advanced_config"`
-	Meta                  Meta      `json:"meta"`
-	AllowWebsocketUpgrade bool      `json:"allow_websocket_upgrade"`
-	HTTP2Support          bool      `json:"http2_support"`
-	ForwardScheme         string    `json:"forward_scheme"`
-	Enabled               bool      `json:"enabled"`
-	Locations             []string  `json:"locations"`
-	HSTSEnabled           bool      `json:"hsts_enabled"`
-	HSTSSubdomains        bool      `json:"hsts_subdomains"`
-}
-
-var authResponse AuthResponse
-
-func auth(username string, password string, url string) (string, error) {
-	fmt.Println("Authenticating to Nginx Proxy Manager")
+// errUnauthorized is returned by get when the token has been rejected
+var errUnauthorized = errors.New("unauthorized")
+
+// refreshBefore is how long before expiry a token is refreshed rather than reused
+const refreshBefore = 5 * time.Minute
+
+// Client talks to a single Nginx Proxy Manager instance. It is safe for concurrent use and
+// keeps its token fresh, so one client can be reused for the life of the process.
+type Client struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token AuthResponse
+}
+
+// NewClient creates a client for the instance at url.
+func NewClient(url string, username string, password string, httpClient *http.Client) *Client {
+	return &Client{
+		url:        strings.TrimRight(url, "/"),
+		username:   username,
+		password:   password,
+		httpClient: httpclient.OrDefault(httpClient),
+	}
+}
+
+// auth returns a usable token, refreshing it when it is close to expiry and logging in when there is none
+func (c *Client) auth() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.Token != "" && time.Now().Before(c.token.Expires.Add(-refreshBefore)) {
+		return c.token.Token, nil
+	}
+
+	if c.token.Token != "" && time.Now().Before(c.token.Expires) {
+		fmt.Println("Refreshing Nginx Proxy Manager token")
+		content, err := c.refresh()
+		if err == nil {
+			c.token = content
+			return content.Token, nil
+		}
+		fmt.Printf("Unable to refresh Nginx Proxy Manager token, logging in again: %s\n", err)
+	}
+
+	content, err := c.login()
+	if err != nil {
+		return "", err
+	}
+	c.token = content
+	return content.Token, nil
+}
 
-	if authResponse.Token != "" && time.Now().Before(authResponse.Expires) {
-		fmt.Println("Using cached token")
-		return authResponse.Token, nil
+// clearToken forgets the current token so the next request logs in again
+func (c *Client) clearToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token.Token == token {
+		c.token = AuthResponse{}
 	}
+}
+
+func (c *Client) login() (AuthResponse, error) {
+	fmt.Println("Authenticating to Nginx Proxy Manager")
 
 	// Create The Request
-	bodyObject := AuthRequest{Identity: username, Secret: password}
+	bodyObject := AuthRequest{Identity: c.username, Secret: c.password}
 	jsonBody, err := json.Marshal(bodyObject)
 	if err != nil {
-		return "", err
+		return AuthResponse{}, err
 	}
 
 	jsonBodyBytes := []byte(jsonBody)
 	bodyReader := bytes.NewReader(jsonBodyBytes)
 
-	req, err := http.NewRequest("POST", url+"/api/tokens", bodyReader)
+	req, err := http.NewRequest("POST", c.url+"/api/tokens", bodyReader)
 	if err != nil {
-		return "", err
+		return AuthResponse{}, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
+	content, err := c.tokenRequest(req)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	fmt.Printf("Authenticated to Nginx Proxy Manager with token, expiring %s\n", content.Expires)
+	return content, nil
+}
+
+// refresh swaps the current token for a new one without sending the password again
+func (c *Client) refresh() (AuthResponse, error) {
+	req, err := http.NewRequest("GET", c.url+"/api/tokens", nil)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	req.Header.Add("Authorization", "Bearer "+c.token.Token)
+	return c.tokenRequest(req)
+}
+
+func (c *Client) tokenRequest(req *http.Request) (AuthResponse, error) {
 	//Make The Request
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return AuthResponse{}, err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
-		return "", errors.New("Failed to authenticate to Nginx Proxy Manager")
+		return AuthResponse{}, fmt.Errorf("Failed to authenticate to Nginx Proxy Manager: %s", res.Status)
 	}
 
 	// Parse The Response
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", err
+		return AuthResponse{}, err
 	}
 	var content AuthResponse
 	err = json.Unmarshal(resBody, &content)
 	if err != nil {
-		return "", err
+		return AuthResponse{}, err
 	}
+	return content, nil
+}
 
-	fmt.Printf("Authenticated to Nginx Proxy Manager with token, expiring %s\n", content.Expires)
+// Host types as named in the Nginx Proxy Manager UI
+const (
+	ProxyHost       = "proxy"
+	RedirectionHost = "redirection"
+	DeadHost        = "dead"
+	StreamHost      = "stream"
+)
 
-	authResponse = content
+// hostPaths maps each host type to the API path that lists it
+var hostPaths = map[string]string{
+	ProxyHost:       "/api/nginx/proxy-hosts",
+	RedirectionHost: "/api/nginx/redirection-hosts",
+	DeadHost:        "/api/nginx/dead-hosts",
+	StreamHost:      "/api/nginx/streams",
+}
 
-	return content.Token, nil
+// Host is any of the host types Nginx Proxy Manager serves. Streams forward a port rather than a
+// domain, so they have no DomainNames.
+type Host struct {
+	Type        string
+	ID          int
+	DomainNames []string
+	Enabled     bool
+	// Online is false when nginx failed to load the host's configuration, Error then holds the reason
+	Online bool
+	Error  string
+	// ForwardHost and ForwardPort are the upstream of a proxy host or stream, empty for other types
+	ForwardHost string
+	ForwardPort int
+	// AccessListID is the access list protecting a proxy host, 0 when it has none
+	AccessListID int
+}
+
+// listedHost holds the fields shared by the proxy, redirection, dead host and stream list responses
+type listedHost struct {
+	ID          int      `json:"id"`
+	DomainNames []string `json:"domain_names"`
+	Enabled     bool     `json:"enabled"`
+	// proxy hosts
+	ForwardHost  string `json:"forward_host"`
+	ForwardPort  int    `json:"forward_port"`
+	AccessListID int    `json:"access_list_id"`
+	// streams
+	ForwardingHost string `json:"forwarding_host"`
+	ForwardingPort int    `json:"forwarding_port"`
+	Meta           struct {
+		NginxOnline *bool   `json:"nginx_online"`
+		NginxErr    *string `json:"nginx_err"`
+	} `json:"meta"`
+}
+
+// SkipReason explains why no records should be published for the host, or returns an empty string
+// if it should be. Disabled hosts are always skipped, hosts nginx failed to load only when skipOffline is set.
+func (h Host) SkipReason(skipOffline bool) string {
+	if !h.Enabled {
+		return "disabled"
+	}
+	if skipOffline && !h.Online {
+		if h.Error != "" {
+			return "offline: " + h.Error
+		}
+		return "offline"
+	}
+	return ""
 }
 
-func GetProxyHosts(username string, password string, url string) ([]string, error) {
+// GetProxyHosts returns the hosts of the given types, proxy hosts only when hostTypes is empty
+func (c *Client) GetProxyHosts(hostTypes []string) ([]Host, error) {
+	if len(hostTypes) == 0 {
+		hostTypes = []string{ProxyHost}
+	}
+
+	var hosts []Host
+	for _, hostType := range hostTypes {
+		path, ok := hostPaths[hostType]
+		if !ok {
+			return nil, fmt.Errorf("unknown Nginx Proxy Manager host type %s", hostType)
+		}
+		var content []listedHost
+		err := c.getList(path, &content)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range content {
+			online := host.Meta.NginxOnline == nil || *host.Meta.NginxOnline
+			nginxErr := ""
+			if host.Meta.NginxErr != nil {
+				nginxErr = strings.TrimSpace(*host.Meta.NginxErr)
+			}
+			forwardHost, forwardPort := host.ForwardHost, host.ForwardPort
+			if hostType == StreamHost {
+				forwardHost, forwardPort = host.ForwardingHost, host.ForwardingPort
+			}
+			hosts = append(hosts, Host{
+				Type:         hostType,
+				ID:           host.ID,
+				DomainNames:  host.DomainNames,
+				Enabled:      host.Enabled,
+				Online:       online && nginxErr == "",
+				Error:        nginxErr,
+				ForwardHost:  strings.ToLower(forwardHost),
+				ForwardPort:  forwardPort,
+				AccessListID: host.AccessListID,
+			})
+		}
+	}
+
+	return hosts, nil
+}
 
-	token, err := auth(username, password, url)
+// GetAccessLists returns the access lists with their client rules and users
+func (c *Client) GetAccessLists() ([]AccessList, error) {
+	var content []AccessList
+	err := c.getList("/api/nginx/access-lists?expand=clients,items", &content)
 	if err != nil {
 		return nil, err
 	}
+	return content, nil
+}
 
-	req, err := http.NewRequest("GET", url+"/api/nginx/proxy-hosts", nil)
+// getList requests path with the current token, logging in again once if the token is rejected
+func (c *Client) getList(path string, v interface{}) error {
+	token, err := c.auth()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	err = c.get(token, path, v)
+	if errors.Is(err, errUnauthorized) {
+		fmt.Println("Nginx Proxy Manager rejected the token, logging in again")
+		c.clearToken(token)
+		token, err = c.auth()
+		if err != nil {
+			return err
+		}
+		err = c.get(token, path, v)
+	}
+	return err
+}
+
+func (c *Client) get(token string, path string, v interface{}) error {
+	req, err := http.NewRequest("GET", c.url+path, nil)
+	if err != nil {
+		return err
 	}
 	req.Header.Add("Authorization", "Bearer "+token)
 
 	//Make The Request
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 401 {
+		return fmt.Errorf("Failed to get %s: %w", path, errUnauthorized)
 	}
 	if res.StatusCode != 200 {
-		return nil, errors.New("Failed to get proxy hosts")
+		return fmt.Errorf("Failed to get %s: %s", path, res.Status)
 	}
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
-	}
-	var content []Config
-	err = json.Unmarshal(resBody, &content)
-	if err != nil {
-		return nil, err
-	}
-	var hosts []string
-	for _, host := range content {
-		hosts = append(hosts, host.DomainNames...)
+		return err
 	}
-
-	return hosts, nil
+	return json.Unmarshal(resBody, v)
 }
@@ -2,9 +2,11 @@ package nginxproxymanager
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestAuth(t *testing.T) {
@@ -23,7 +25,7 @@ func TestAuth(t *testing.T) {
 
 	}))
 	defer server.Close()
-	token, err := auth("user", "pass", server.URL)
+	token, err := NewClient(server.URL, "user", "pass", nil).auth()
 	if err != nil {
 		t.Errorf("Error authenticating: %s", err)
 	}
@@ -31,3 +33,159 @@ func TestAuth(t *testing.T) {
 		t.Errorf("Expected token test, got %s", token)
 	}
 }
+
+func TestGetProxyHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/api/tokens":
+			response = AuthResponse{Token: "test"}
+		case "/api/nginx/proxy-hosts":
+			response = []listedHost{{ID: 1, DomainNames: []string{"files.awesome.com"}, AccessListID: 4}}
+		case "/api/nginx/redirection-hosts":
+			response = []listedHost{{ID: 2, DomainNames: []string{"www.awesome.com", "old.awesome.com"}}}
+		case "/api/nginx/streams":
+			response = []map[string]interface{}{{"id": 3, "incoming_port": 2222, "forwarding_host": "git.lan"}}
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Path != "/api/tokens" && r.Header.Get("Authorization") != "Bearer test" {
+			t.Errorf("Expected bearer token, got %s", r.Header.Get("Authorization"))
+		}
+
+		responseJson, err := json.Marshal(response)
+		if err != nil {
+			t.Errorf("Error marshalling response: %s", err)
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(responseJson))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "user", "pass", nil)
+
+	hosts, err := client.GetProxyHosts([]string{ProxyHost, RedirectionHost, StreamHost})
+	if err != nil {
+		t.Errorf("Error getting hosts: %s", err)
+	}
+	if len(hosts) != 3 {
+		t.Fatalf("Expected 3 hosts, got %d", len(hosts))
+	}
+	if hosts[0].AccessListID != 4 {
+		t.Errorf("Expected access list 4, got %d", hosts[0].AccessListID)
+	}
+	if hosts[1].Type != RedirectionHost || len(hosts[1].DomainNames) != 2 {
+		t.Errorf("Expected redirection host with 2 domains, got %v", hosts[1])
+	}
+	if hosts[2].Type != StreamHost || len(hosts[2].DomainNames) != 0 {
+		t.Errorf("Expected stream without domains, got %v", hosts[2])
+	}
+
+	_, err = client.GetProxyHosts([]string{"unknown"})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown host type")
+	}
+}
+
+func TestHostState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		if r.URL.Path == "/api/tokens" {
+			w.Write([]byte(`{"token": "test"}`))
+			return
+		}
+		w.Write([]byte(`[
+			{"id": 1, "domain_names": ["up.awesome.com"], "enabled": true, "meta": {"nginx_online": true, "nginx_err": null}},
+			{"id": 2, "domain_names": ["off.awesome.com"], "enabled": false, "meta": {"nginx_online": true, "nginx_err": null}},
+			{"id": 3, "domain_names": ["broken.awesome.com"], "enabled": true, "meta": {"nginx_online": false, "nginx_err": "cert missing"}}
+		]`))
+	}))
+	defer server.Close()
+	hosts, err := NewClient(server.URL, "user", "pass", nil).GetProxyHosts(nil)
+	if err != nil {
+		t.Fatalf("Error getting hosts: %s", err)
+	}
+	expected := []struct {
+		skipOffline bool
+		reasons     []string
+	}{
+		{false, []string{"", "disabled", ""}},
+		{true, []string{"", "disabled", "offline: cert missing"}},
+	}
+	for _, e := range expected {
+		for idx, host := range hosts {
+			if reason := host.SkipReason(e.skipOffline); reason != e.reasons[idx] {
+				t.Errorf("Expected %s to be skipped with %q when skipOffline is %t, got %q", host.DomainNames[0], e.reasons[idx], e.skipOffline, reason)
+			}
+		}
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	logins := 0
+	refreshes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response AuthResponse
+		switch {
+		case r.URL.Path == "/api/tokens" && r.Method == "POST":
+			logins++
+			// expires inside the refresh window so the next request refreshes it
+			response = AuthResponse{Token: "login", Expires: time.Now().Add(time.Minute)}
+		case r.URL.Path == "/api/tokens" && r.Method == "GET":
+			refreshes++
+			if r.Header.Get("Authorization") != "Bearer login" {
+				t.Errorf("Expected refresh with the current token, got %s", r.Header.Get("Authorization"))
+			}
+			response = AuthResponse{Token: "refreshed", Expires: time.Now().Add(time.Hour)}
+		}
+		responseJson, err := json.Marshal(response)
+		if err != nil {
+			t.Errorf("Error marshalling response: %s", err)
+		}
+		w.WriteHeader(200)
+		w.Write(responseJson)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "user", "pass", nil)
+
+	for _, expected := range []string{"login", "refreshed", "refreshed"} {
+		token, err := client.auth()
+		if err != nil {
+			t.Fatalf("Error authenticating: %s", err)
+		}
+		if token != expected {
+			t.Errorf("Expected token %s, got %s", expected, token)
+		}
+	}
+	if logins != 1 || refreshes != 1 {
+		t.Errorf("Expected 1 login and 1 refresh, got %d and %d", logins, refreshes)
+	}
+}
+
+func TestReauthenticateOnUnauthorized(t *testing.T) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tokens" {
+			logins++
+			responseJson, _ := json.Marshal(AuthResponse{Token: fmt.Sprintf("token-%d", logins), Expires: time.Now().Add(time.Hour)})
+			w.WriteHeader(200)
+			w.Write(responseJson)
+			return
+		}
+		// the first token has been revoked on the server
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(`[{"id": 1, "domain_names": ["files.awesome.com"], "enabled": true}]`))
+	}))
+	defer server.Close()
+
+	hosts, err := NewClient(server.URL, "user", "pass", nil).GetProxyHosts(nil)
+	if err != nil {
+		t.Fatalf("Error getting hosts: %s", err)
+	}
+	if len(hosts) != 1 || logins != 2 {
+		t.Errorf("Expected 1 host after logging in twice, got %d hosts and %d logins", len(hosts), logins)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	urlProcessor "net/url"
 	"strings"
 	"time"
+	"unipidns/internal/httpclient"
 )
 
 type AuthSession struct {
@@ -31,6 +32,14 @@ type AuthRequest struct {
 
 var authResponse AuthResponse
 
+// httpClient makes every request, SetTimeout changes its timeout for the next PiHole
+var httpClient = &http.Client{Timeout: httpclient.DefaultTimeout}
+
+// SetTimeout sets how long each request may take
+func SetTimeout(timeout time.Duration) {
+	httpClient.Timeout = timeout
+}
+
 func auth(url string, password string) error {
 	if authResponse.Session.Valid && time.Now().Before(authResponse.Expires) {
 		fmt.Println("Using cached session")
@@ -54,7 +63,7 @@ func auth(url string, password string) error {
 	req.Header.Add("Content-Type", "application/json")
 
 	// make the request
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -96,43 +105,56 @@ func addHeaders(req *http.Request) {
 }
 
 func GetLocalDns(url string, password string) ([]string, []string, error) {
-	err := auth(url, password)
+	content, err := getConfig(url, password)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	err = auth(url, password)
+	return content.Config.DNS.Hosts, content.Config.DNS.CnameRecords, nil
+
+}
+
+// GetDnsmasqLines returns the custom dnsmasq lines (misc.dnsmasq_lines) configured on the PiHole
+func GetDnsmasqLines(url string, password string) ([]string, error) {
+	content, err := getConfig(url, password)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	return content.Config.Misc.DnsmasqLines, nil
+}
+
+func getConfig(url string, password string) (*ConfigResponse, error) {
+	err := auth(url, password)
+	if err != nil {
+		return nil, err
 	}
 
 	// create the request
 	req, err := http.NewRequest("GET", url+"/api/config", nil)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	addHeaders(req)
 
 	// make the request
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if res.StatusCode != 200 {
-		return nil, nil, fmt.Errorf("failed to get PiHole config: %s", res.Status)
+		return nil, fmt.Errorf("failed to get PiHole config: %s", res.Status)
 	}
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	var content ConfigResponse
 	err = json.Unmarshal(resBody, &content)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	return content.Config.DNS.Hosts, content.Config.DNS.CnameRecords, nil
-
+	return &content, nil
 }
 
 func AddLocalDns(url string, password string, host string, ip string) error {
@@ -151,6 +173,41 @@ func RemoveCname(url string, password string, host string, redirect string) erro
 	return cname(url, password, host, redirect, "DELETE")
 }
 
+func AddDnsmasqLine(url string, password string, line string) error {
+	return dnsmasqLine(url, password, line, "PUT")
+}
+
+func RemoveDnsmasqLine(url string, password string, line string) error {
+	return dnsmasqLine(url, password, line, "DELETE")
+}
+
+func dnsmasqLine(url string, password string, line string, verb string) error {
+	err := auth(url, password)
+	if err != nil {
+		return err
+	}
+	// create the request, lines contain slashes so the value has to be escaped as a single path segment
+	req, err := http.NewRequest(verb, strings.TrimRight(url, "/")+"/api/config/misc/dnsmasq_lines/"+urlProcessor.PathEscape(line), nil)
+	if err != nil {
+		return err
+	}
+	addHeaders(req)
+
+	// Make the request
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if verb == "PUT" && res.StatusCode != 201 && res.StatusCode != 400 { // 400 is returned if the line already exists
+		return fmt.Errorf("failed to %s dnsmasq line: %s", verb, res.Status)
+	}
+	if verb == "DELETE" && res.StatusCode != 204 {
+		return fmt.Errorf("failed to %s dnsmasq line: %s", verb, res.Status)
+	}
+
+	return nil
+}
+
 func cname(url string, password string, host string, redirect string, verb string) error {
 	err := auth(url, password)
 	if err != nil {
@@ -171,7 +228,7 @@ func cname(url string, password string, host string, redirect string, verb strin
 	addHeaders(req)
 
 	// Make the request
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -205,7 +262,7 @@ func localDns(url string, password string, host string, ip string, verb string)
 	addHeaders(req)
 
 	// Make the request
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
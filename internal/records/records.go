@@ -0,0 +1,204 @@
+package records
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Record types
+const (
+	A     = "A"
+	AAAA  = "AAAA"
+	CNAME = "CNAME"
+	PTR   = "PTR"
+	TXT   = "TXT"
+)
+
+// Record is a single record in the desired state every DNS target is synced to. Names are fully
+// qualified without the trailing dot, and wildcard names start with "*.".
+type Record struct {
+	Type  string
+	Name  string
+	Value string
+}
+
+// Address returns an A or AAAA record for ip depending on its family
+func Address(name string, ip string) Record {
+	recordType := A
+	if addr, err := netip.ParseAddr(ip); err == nil && addr.Is6() && !addr.Is4In6() {
+		recordType = AAAA
+	}
+	return Record{Type: recordType, Name: strings.ToLower(name), Value: ip}
+}
+
+// Cname returns a CNAME record from name to target
+func Cname(name string, target string) Record {
+	return Record{Type: CNAME, Name: strings.ToLower(name), Value: strings.ToLower(target)}
+}
+
+// ParseHost reads a PiHole style "ip name" host line
+func ParseHost(line string) (Record, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return Record{}, false
+	}
+	return Address(fields[1], fields[0]), true
+}
+
+// ParseCname reads a PiHole style "name,target" CNAME line, ignoring any TTL after the target
+func ParseCname(line string) (Record, bool) {
+	fields := strings.Split(line, ",")
+	if len(fields) < 2 {
+		return Record{}, false
+	}
+	return Cname(fields[0], fields[1]), true
+}
+
+// Wildcard reports whether the record answers for everything below its name
+func (r Record) Wildcard() bool {
+	return strings.HasPrefix(r.Name, "*.")
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%s %s %s", r.Type, r.Name, r.Value)
+}
+
+// Set is the desired state built from Unifi and the web edge sources
+type Set struct {
+	Records []Record
+}
+
+// Add adds records that are not already in the set
+func (s *Set) Add(records ...Record) {
+	for _, record := range records {
+		if !slices.Contains(s.Records, record) {
+			s.Records = append(s.Records, record)
+		}
+	}
+}
+
+// Cnames returns the CNAME records as "name,target" lines, wildcards excluded
+func (s *Set) Cnames() []string {
+	var lines []string
+	for _, record := range s.Records {
+		if record.Type == CNAME && !record.Wildcard() {
+			lines = append(lines, fmt.Sprintf("%s,%s", record.Name, record.Value))
+		}
+	}
+	return lines
+}
+
+// Wildcards returns the wildcard records
+func (s *Set) Wildcards() []Record {
+	var wildcards []Record
+	for _, record := range s.Records {
+		if record.Wildcard() {
+			wildcards = append(wildcards, record)
+		}
+	}
+	return wildcards
+}
+
+// Ptrs returns a PTR record for every address in the set, pointing at the first name it was added
+// with. That is the Unifi client name rather than an alias, since clients are added first.
+func (s *Set) Ptrs() []Record {
+	var ptrs []Record
+	seen := map[string]bool{}
+	for _, record := range s.Records {
+		if (record.Type != A && record.Type != AAAA) || record.Wildcard() || seen[record.Value] {
+			continue
+		}
+		reverse, ok := ReverseName(record.Value)
+		if !ok {
+			continue
+		}
+		seen[record.Value] = true
+		ptrs = append(ptrs, Record{Type: PTR, Name: reverse, Value: record.Name})
+	}
+	return ptrs
+}
+
+// Flatten returns the A and AAAA records with every CNAME replaced by address records for the addresses
+// its target resolves to within the set, for formats that can't hold a CNAME. CNAMEs that don't lead
+// to an address in the set are returned separately.
+func (s *Set) Flatten() ([]Record, []Record) {
+	addresses := map[string][]string{}
+	targets := map[string]string{}
+	var flattened []Record
+	for _, record := range s.Records {
+		switch record.Type {
+		case A, AAAA:
+			addresses[record.Name] = append(addresses[record.Name], record.Value)
+			flattened = append(flattened, record)
+		case CNAME:
+			targets[record.Name] = record.Value
+		}
+	}
+
+	var unresolved []Record
+	for _, record := range s.Records {
+		if record.Type != CNAME {
+			continue
+		}
+		target := record.Value
+		// follow chains of CNAMEs, giving up on loops
+		for i := 0; i < 10 && addresses[target] == nil && targets[target] != ""; i++ {
+			target = targets[target]
+		}
+		if addresses[target] == nil {
+			unresolved = append(unresolved, record)
+			continue
+		}
+		for _, address := range addresses[target] {
+			flattened = append(flattened, Address(record.Name, address))
+		}
+	}
+	return flattened, unresolved
+}
+
+// InZone reports whether name is zone or below it
+func InZone(name string, zone string) bool {
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// Diff returns the records in desired that are missing from current, and the ones in current that
+// are no longer desired. owned limits what may be removed, nil means everything in current is owned.
+func Diff(current []Record, desired []Record, owned func(Record) bool) ([]Record, []Record) {
+	var add []Record
+	var remove []Record
+	for _, record := range desired {
+		if !slices.Contains(current, record) && !slices.Contains(add, record) {
+			add = append(add, record)
+		}
+	}
+	for _, record := range current {
+		if !slices.Contains(desired, record) && (owned == nil || owned(record)) {
+			remove = append(remove, record)
+		}
+	}
+	return add, remove
+}
+
+// ReverseName returns the in-addr.arpa or ip6.arpa name for the PTR record of ip
+func ReverseName(ip string) (string, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+	var labels []string
+	if addr.Is4() {
+		octets := addr.As4()
+		for i := len(octets) - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprint(octets[i]))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa", true
+	}
+	hex := fmt.Sprintf("%x", addr.As16())
+	for i := len(hex) - 1; i >= 0; i-- {
+		labels = append(labels, string(hex[i]))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa", true
+}
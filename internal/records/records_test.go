@@ -0,0 +1,98 @@
+package records
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddress(t *testing.T) {
+	if record := Address("NAS.lan", "192.168.1.10"); record != (Record{A, "nas.lan", "192.168.1.10"}) {
+		t.Errorf("Expected an A record, got %v", record)
+	}
+	if record := Address("nas.lan", "fd00::10"); record.Type != AAAA {
+		t.Errorf("Expected an AAAA record, got %v", record)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if record, ok := ParseHost("192.168.1.10 nas.lan"); !ok || record != Address("nas.lan", "192.168.1.10") {
+		t.Errorf("Expected nas.lan host, got %v", record)
+	}
+	if _, ok := ParseHost("192.168.1.10"); ok {
+		t.Errorf("Expected a host line without a name to fail")
+	}
+	if record, ok := ParseCname("files.awesome.com,edge.lan,300"); !ok || record != Cname("files.awesome.com", "edge.lan") {
+		t.Errorf("Expected files.awesome.com CNAME, got %v", record)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var set Set
+	set.Add(Address("nas.lan", "192.168.1.10"), Address("nas.lan", "192.168.1.10"), Cname("files.awesome.com", "nas.lan"), Address("*.apps.awesome.com", "192.168.1.2"))
+	if len(set.Records) != 3 {
+		t.Errorf("Expected duplicates to be dropped, got %v", set.Records)
+	}
+	if !slices.Equal(set.Cnames(), []string{"files.awesome.com,nas.lan"}) {
+		t.Errorf("Expected one CNAME line, got %v", set.Cnames())
+	}
+	if len(set.Wildcards()) != 1 {
+		t.Errorf("Expected one wildcard, got %v", set.Wildcards())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	keep := Address("nas.lan", "192.168.1.10")
+	stale := Address("old.lan", "192.168.1.11")
+	manual := Address("router.home", "192.168.1.1")
+	added := Cname("files.awesome.com", "nas.lan")
+
+	add, remove := Diff([]Record{keep, stale, manual}, []Record{keep, added}, func(r Record) bool { return r.Name != "router.home" })
+	if !slices.Equal(add, []Record{added}) {
+		t.Errorf("Expected to add %v, got %v", added, add)
+	}
+	if !slices.Equal(remove, []Record{stale}) {
+		t.Errorf("Expected to remove %v, got %v", stale, remove)
+	}
+
+	_, remove = Diff([]Record{keep, manual}, []Record{keep}, nil)
+	if !slices.Equal(remove, []Record{manual}) {
+		t.Errorf("Expected everything to be owned with no owned func, got %v", remove)
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	tests := map[string]string{
+		"192.168.1.10": "10.1.168.192.in-addr.arpa",
+		"fd00::1":      "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa",
+	}
+	for ip, want := range tests {
+		if got, ok := ReverseName(ip); !ok || got != want {
+			t.Errorf("Expected %s for %s, got %s", want, ip, got)
+		}
+	}
+	if _, ok := ReverseName("nas.lan"); ok {
+		t.Errorf("Expected a name to have no reverse name")
+	}
+}
+
+func TestPtrs(t *testing.T) {
+	var set Set
+	set.Add(Address("nas.lan", "192.168.1.10"), Address("files.lan", "192.168.1.10"), Address("*.apps.awesome.com", "192.168.1.2"), Cname("www.lan", "nas.lan"))
+	want := []Record{{Type: PTR, Name: "10.1.168.192.in-addr.arpa", Value: "nas.lan"}}
+	if !slices.Equal(set.Ptrs(), want) {
+		t.Errorf("Expected %v, got %v", want, set.Ptrs())
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	var set Set
+	set.Add(Address("nas.lan", "192.168.1.10"), Cname("files.lan", "nas.lan"), Cname("files.awesome.com", "files.lan"), Cname("away.awesome.com", "elsewhere.com"))
+	flattened, unresolved := set.Flatten()
+	want := []Record{Address("nas.lan", "192.168.1.10"), Address("files.lan", "192.168.1.10"), Address("files.awesome.com", "192.168.1.10")}
+	if !slices.Equal(flattened, want) {
+		t.Errorf("Expected %v, got %v", want, flattened)
+	}
+	if !slices.Equal(unresolved, []Record{Cname("away.awesome.com", "elsewhere.com")}) {
+		t.Errorf("Expected the CNAME outside the set to be unresolved, got %v", unresolved)
+	}
+}
@@ -0,0 +1,191 @@
+package rfc2136
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Client reads zones with AXFR and changes them with RFC 2136 UPDATE messages, both signed with TSIG
+// when a key is given
+type Client struct {
+	server    string
+	keyName   string
+	secret    string
+	algorithm string
+	timeout   time.Duration
+}
+
+// Record is an NS, A, AAAA, CNAME, PTR or TXT record. Names are fully qualified without the trailing dot.
+type Record struct {
+	Name  string
+	Type  string
+	Value string
+	TTL   uint32
+}
+
+// NewClient creates a client for server, a host with an optional port which defaults to 53. algorithm
+// is a TSIG algorithm such as hmac-sha256, which is used when it is empty. timeout applies to each exchange.
+func NewClient(server string, keyName string, secret string, algorithm string, timeout time.Duration) *Client {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	if algorithm == "" {
+		algorithm = dns.HmacSHA256
+	}
+	return &Client{
+		server:    server,
+		keyName:   dns.Fqdn(keyName),
+		secret:    secret,
+		algorithm: dns.Fqdn(strings.ToLower(algorithm)),
+		timeout:   timeout,
+	}
+}
+
+func (c *Client) tsigSecret() map[string]string {
+	if c.secret == "" {
+		return nil
+	}
+	return map[string]string{c.keyName: c.secret}
+}
+
+func (c *Client) sign(m *dns.Msg) {
+	if c.secret != "" {
+		m.SetTsig(c.keyName, c.algorithm, 300, time.Now().Unix())
+	}
+}
+
+// Transfer returns the NS, A, AAAA, CNAME, PTR and TXT records in zone
+func (c *Client) Transfer(zone string) ([]Record, error) {
+	m := new(dns.Msg)
+	m.SetAxfr(dns.Fqdn(zone))
+	c.sign(m)
+	transfer := &dns.Transfer{
+		DialTimeout:  c.timeout,
+		ReadTimeout:  c.timeout,
+		WriteTimeout: c.timeout,
+		TsigSecret:   c.tsigSecret(),
+	}
+	envelopes, err := transfer.In(m, c.server)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("zone transfer of %s failed: %w", zone, envelope.Error)
+		}
+		for _, rr := range envelope.RR {
+			if record, ok := fromRR(rr); ok && !containsRecord(records, record) {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+// Update removes and then adds records in zone in a single UPDATE message
+func (c *Client) Update(zone string, add []Record, remove []Record) error {
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(zone))
+	var removeRRs []dns.RR
+	for _, record := range remove {
+		rr, err := toRR(record)
+		if err != nil {
+			return err
+		}
+		removeRRs = append(removeRRs, rr)
+	}
+	var addRRs []dns.RR
+	for _, record := range add {
+		rr, err := toRR(record)
+		if err != nil {
+			return err
+		}
+		addRRs = append(addRRs, rr)
+	}
+	if len(removeRRs) != 0 {
+		m.Remove(removeRRs)
+	}
+	if len(addRRs) != 0 {
+		m.Insert(addRRs)
+	}
+	c.sign(m)
+
+	client := &dns.Client{Net: "tcp", Timeout: c.timeout, TsigSecret: c.tsigSecret()}
+	res, _, err := client.Exchange(m, c.server)
+	if err != nil {
+		return err
+	}
+	if res.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("update of %s was refused: %s", zone, dns.RcodeToString[res.Rcode])
+	}
+	return nil
+}
+
+func fromRR(rr dns.RR) (Record, bool) {
+	record := Record{Name: name(rr.Header().Name), TTL: rr.Header().Ttl}
+	switch rr := rr.(type) {
+	case *dns.A:
+		record.Type, record.Value = "A", rr.A.String()
+	case *dns.AAAA:
+		record.Type, record.Value = "AAAA", rr.AAAA.String()
+	case *dns.CNAME:
+		record.Type, record.Value = "CNAME", name(rr.Target)
+	case *dns.PTR:
+		record.Type, record.Value = "PTR", name(rr.Ptr)
+	case *dns.NS:
+		record.Type, record.Value = "NS", name(rr.Ns)
+	case *dns.TXT:
+		record.Type, record.Value = "TXT", strings.Join(rr.Txt, "")
+	default:
+		return Record{}, false
+	}
+	return record, true
+}
+
+func toRR(record Record) (dns.RR, error) {
+	header := dns.RR_Header{Name: dns.Fqdn(record.Name), Class: dns.ClassINET, Ttl: record.TTL}
+	switch record.Type {
+	case "A", "AAAA":
+		ip := net.ParseIP(record.Value)
+		if ip == nil {
+			return nil, fmt.Errorf("%s is not an IP address", record.Value)
+		}
+		if record.Type == "A" {
+			header.Rrtype = dns.TypeA
+			return &dns.A{Hdr: header, A: ip}, nil
+		}
+		header.Rrtype = dns.TypeAAAA
+		return &dns.AAAA{Hdr: header, AAAA: ip}, nil
+	case "CNAME":
+		header.Rrtype = dns.TypeCNAME
+		return &dns.CNAME{Hdr: header, Target: dns.Fqdn(record.Value)}, nil
+	case "PTR":
+		header.Rrtype = dns.TypePTR
+		return &dns.PTR{Hdr: header, Ptr: dns.Fqdn(record.Value)}, nil
+	case "TXT":
+		header.Rrtype = dns.TypeTXT
+		return &dns.TXT{Hdr: header, Txt: []string{record.Value}}, nil
+	}
+	return nil, errors.New("unsupported record type " + record.Type)
+}
+
+func name(fqdn string) string {
+	return strings.ToLower(strings.TrimSuffix(fqdn, "."))
+}
+
+// containsRecord ignores the TTL, as AXFR repeats the SOA at the end of the zone
+func containsRecord(records []Record, record Record) bool {
+	for _, r := range records {
+		if r.Name == record.Name && r.Type == record.Type && r.Value == record.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package rfc2136
+
+import (
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	testKey    = "unipidns."
+	testSecret = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"
+)
+
+// testServer is an authoritative server for one zone that answers AXFR and applies updates, refusing
+// anything not signed with the test key
+type testServer struct {
+	mu   sync.Mutex
+	zone string
+	rrs  []dns.RR
+}
+
+func (s *testServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if r.IsTsig() == nil || w.TsigStatus() != nil {
+		m.SetRcode(r, dns.RcodeRefused)
+		w.WriteMsg(m)
+		return
+	}
+
+	soa, _ := dns.NewRR(s.zone + " 3600 IN SOA ns." + s.zone + " admin." + s.zone + " 1 3600 600 86400 300")
+	switch {
+	case r.Opcode == dns.OpcodeUpdate:
+		for _, rr := range r.Ns {
+			if rr.Header().Class == dns.ClassNONE {
+				s.rrs = slices.DeleteFunc(s.rrs, func(existing dns.RR) bool {
+					removed := dns.Copy(rr)
+					removed.Header().Class = dns.ClassINET
+					removed.Header().Ttl = existing.Header().Ttl
+					return dns.IsDuplicate(existing, removed)
+				})
+				continue
+			}
+			s.rrs = append(s.rrs, rr)
+		}
+	case r.Question[0].Qtype == dns.TypeAXFR:
+		m.Answer = append([]dns.RR{soa}, s.rrs...)
+		m.Answer = append(m.Answer, soa)
+	}
+	m.SetTsig(testKey, dns.HmacSHA256, 300, time.Now().Unix())
+	w.WriteMsg(m)
+}
+
+func startServer(t *testing.T, zone string, rrs ...string) (*testServer, string) {
+	handler := &testServer{zone: zone}
+	for _, text := range rrs {
+		rr, err := dns.NewRR(text)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %s", text, err)
+		}
+		handler.rrs = append(handler.rrs, rr)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %s", err)
+	}
+	started := make(chan struct{})
+	server := &dns.Server{
+		Listener:          listener,
+		Handler:           handler,
+		TsigSecret:        map[string]string{testKey: testSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// the default refuses UPDATE messages
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return handler, listener.Addr().String()
+}
+
+func TestTransferAndUpdate(t *testing.T) {
+	_, address := startServer(t, "lan.", "nas.lan. 3600 IN A 192.168.1.10", "old.lan. 3600 IN A 192.168.1.11", "lan. 3600 IN NS ns.lan.", "ns.lan. 3600 IN A 192.168.1.2")
+	client := NewClient(address, "unipidns", testSecret, "", time.Second)
+
+	records, err := client.Transfer("lan")
+	if err != nil {
+		t.Fatalf("Error transferring zone: %s", err)
+	}
+	if len(records) != 4 || records[0] != (Record{Name: "nas.lan", Type: "A", Value: "192.168.1.10", TTL: 3600}) || records[2] != (Record{Name: "lan", Type: "NS", Value: "ns.lan", TTL: 3600}) {
+		t.Errorf("Expected the NS and three A records, got %v", records)
+	}
+
+	add := []Record{{Name: "files.lan", Type: "CNAME", Value: "nas.lan", TTL: 300}, {Name: "_unipidns.files.lan", Type: "TXT", Value: "unipidns", TTL: 300}}
+	err = client.Update("lan", add, []Record{{Name: "old.lan", Type: "A", Value: "192.168.1.11"}})
+	if err != nil {
+		t.Fatalf("Error updating zone: %s", err)
+	}
+	records, err = client.Transfer("lan")
+	if err != nil {
+		t.Fatalf("Error transferring zone: %s", err)
+	}
+	want := []Record{
+		{Name: "nas.lan", Type: "A", Value: "192.168.1.10", TTL: 3600},
+		{Name: "lan", Type: "NS", Value: "ns.lan", TTL: 3600},
+		{Name: "ns.lan", Type: "A", Value: "192.168.1.2", TTL: 3600},
+		{Name: "files.lan", Type: "CNAME", Value: "nas.lan", TTL: 300},
+		{Name: "_unipidns.files.lan", Type: "TXT", Value: "unipidns", TTL: 300},
+	}
+	if !slices.Equal(records, want) {
+		t.Errorf("Expected %v, got %v", want, records)
+	}
+}
+
+func TestUnsignedUpdateRefused(t *testing.T) {
+	_, address := startServer(t, "lan.")
+	err := NewClient(address, "", "", "", time.Second).Update("lan", []Record{{Name: "nas.lan", Type: "A", Value: "192.168.1.10"}}, nil)
+	if err == nil {
+		t.Errorf("Expected an unsigned update to be refused")
+	}
+}
@@ -0,0 +1,165 @@
+package technitium
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	urlProcessor "net/url"
+	"strings"
+	"unipidns/internal/httpclient"
+)
+
+// Client manages zones and records on a Technitium DNS Server using an API token
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+type Zone struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+// Record is an A, AAAA, CNAME or PTR record. Value is the address, CNAME target or PTR name.
+// Comments is the free text note Technitium keeps with a record.
+type Record struct {
+	Name     string
+	Type     string
+	Value    string
+	Disabled bool
+	Comments string
+}
+
+// response is the envelope every API call answers with
+type response struct {
+	Status       string          `json:"status"`
+	ErrorMessage string          `json:"errorMessage"`
+	Response     json.RawMessage `json:"response"`
+}
+
+type listedRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+	Comments string `json:"comments"`
+	RData    struct {
+		IpAddress string `json:"ipAddress"`
+		Cname     string `json:"cname"`
+		PtrName   string `json:"ptrName"`
+	} `json:"rData"`
+}
+
+// NewClient creates a client for the server at url, usually http://host:5380.
+func NewClient(url string, token string, httpClient *http.Client) *Client {
+	return &Client{url: strings.TrimRight(url, "/"), token: token, httpClient: httpclient.OrDefault(httpClient)}
+}
+
+func (c *Client) GetZones() ([]Zone, error) {
+	var content struct {
+		Zones []Zone `json:"zones"`
+	}
+	err := c.call("/api/zones/list", urlProcessor.Values{}, &content)
+	if err != nil {
+		return nil, err
+	}
+	return content.Zones, nil
+}
+
+// CreateZone creates a primary zone
+func (c *Client) CreateZone(zone string) error {
+	return c.call("/api/zones/create", urlProcessor.Values{"zone": {zone}, "type": {"Primary"}}, nil)
+}
+
+// GetRecords returns the A, AAAA, CNAME and PTR records in zone, other types are left out
+func (c *Client) GetRecords(zone string) ([]Record, error) {
+	var content struct {
+		Records []listedRecord `json:"records"`
+	}
+	err := c.call("/api/zones/records/get", urlProcessor.Values{"domain": {zone}, "zone": {zone}, "listZone": {"true"}}, &content)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, listed := range content.Records {
+		record := Record{Name: strings.ToLower(listed.Name), Type: listed.Type, Disabled: listed.Disabled, Comments: listed.Comments}
+		switch listed.Type {
+		case "A", "AAAA":
+			record.Value = listed.RData.IpAddress
+		case "CNAME":
+			record.Value = strings.ToLower(listed.RData.Cname)
+		case "PTR":
+			record.Value = strings.ToLower(listed.RData.PtrName)
+		default:
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// AddRecord adds record to zone along with its comments. With ptr set an A or AAAA record also gets a PTR record, creating the
+// reverse zone if needed.
+func (c *Client) AddRecord(zone string, record Record, ptr bool) error {
+	params := recordParams(zone, record)
+	if record.Comments != "" {
+		params.Set("comments", record.Comments)
+	}
+	if ptr && (record.Type == "A" || record.Type == "AAAA") {
+		params.Set("ptr", "true")
+		params.Set("createPtrZone", "true")
+	}
+	return c.call("/api/zones/records/add", params, nil)
+}
+
+// DeleteRecord deletes record from zone. An empty zone lets the server find the zone holding it.
+func (c *Client) DeleteRecord(zone string, record Record) error {
+	return c.call("/api/zones/records/delete", recordParams(zone, record), nil)
+}
+
+func recordParams(zone string, record Record) urlProcessor.Values {
+	params := urlProcessor.Values{"domain": {record.Name}, "type": {record.Type}}
+	if zone != "" {
+		params.Set("zone", zone)
+	}
+	switch record.Type {
+	case "A", "AAAA":
+		params.Set("ipAddress", record.Value)
+	case "CNAME":
+		params.Set("cname", record.Value)
+	case "PTR":
+		params.Set("ptrName", record.Value)
+	}
+	return params
+}
+
+// call posts params to path with the token, so it stays out of any request logs
+func (c *Client) call(path string, params urlProcessor.Values, v interface{}) error {
+	params.Set("token", c.token)
+	res, err := c.httpClient.PostForm(c.url+path, params)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("Failed to call %s on Technitium: %s", path, res.Status)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	var content response
+	err = json.Unmarshal(resBody, &content)
+	if err != nil {
+		return err
+	}
+	if content.Status != "ok" {
+		return fmt.Errorf("Failed to call %s on Technitium: %s %s", path, content.Status, content.ErrorMessage)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(content.Response, v)
+}
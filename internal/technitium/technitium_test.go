@@ -0,0 +1,67 @@
+package technitium
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGetRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("token") != "secret" {
+			t.Errorf("Expected token secret, got %s", r.FormValue("token"))
+		}
+		if r.URL.Path != "/api/zones/records/get" || r.FormValue("zone") != "lan" || r.FormValue("listZone") != "true" {
+			t.Errorf("Unexpected request %s %v", r.URL.Path, r.Form)
+		}
+		w.Write([]byte(`{"status": "ok", "response": {"records": [
+			{"name": "lan", "type": "SOA", "rData": {"primaryNameServer": "dns.lan"}},
+			{"name": "NAS.lan", "type": "A", "comments": "unipidns", "rData": {"ipAddress": "192.168.1.10"}},
+			{"name": "files.lan", "type": "CNAME", "disabled": true, "rData": {"cname": "nas.lan"}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	records, err := NewClient(server.URL, "secret", nil).GetRecords("lan")
+	if err != nil {
+		t.Fatalf("Error getting records: %s", err)
+	}
+	want := []Record{{Name: "nas.lan", Type: "A", Value: "192.168.1.10", Comments: "unipidns"}, {Name: "files.lan", Type: "CNAME", Value: "nas.lan", Disabled: true}}
+	if !slices.Equal(records, want) {
+		t.Errorf("Expected %v, got %v", want, records)
+	}
+}
+
+func TestAddRecord(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/zones/records/add" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.FormValue("ipAddress") != "192.168.1.10" || r.FormValue("ptr") != "true" || r.FormValue("createPtrZone") != "true" {
+			t.Errorf("Expected an A record with a PTR, got %v", r.Form)
+		}
+		if r.FormValue("comments") != "unipidns" {
+			t.Errorf("Expected comments unipidns, got %s", r.FormValue("comments"))
+		}
+		w.Write([]byte(`{"status": "ok", "response": {}}`))
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, "secret", nil).AddRecord("lan", Record{Name: "nas.lan", Type: "A", Value: "192.168.1.10", Comments: "unipidns"}, true)
+	if err != nil {
+		t.Errorf("Error adding record: %s", err)
+	}
+}
+
+func TestApiError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "invalid-token", "errorMessage": "Invalid token or session expired."}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "wrong", nil).GetZones()
+	if err == nil {
+		t.Errorf("Expected an error for an invalid token")
+	}
+}
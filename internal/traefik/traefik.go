@@ -0,0 +1,121 @@
+package traefik
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unipidns/internal/httpclient"
+)
+
+// Client reads routers from the Traefik API. The API is usually only protected by basic auth in
+// front of it, so username and password are optional.
+type Client struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// Router is an HTTP or TCP router as reported by /api/http/routers and /api/tcp/routers
+type Router struct {
+	Name     string `json:"name"`
+	Rule     string `json:"rule"`
+	Status   string `json:"status"`
+	Provider string `json:"provider"`
+}
+
+// NewClient creates a client for the Traefik API at url.
+func NewClient(url string, username string, password string, httpClient *http.Client) *Client {
+	return &Client{
+		url:        strings.TrimRight(url, "/"),
+		username:   username,
+		password:   password,
+		httpClient: httpclient.OrDefault(httpClient),
+	}
+}
+
+// GetRouters returns the HTTP routers followed by the TCP routers, which are where HostSNI rules live
+func (c *Client) GetRouters() ([]Router, error) {
+	var routers []Router
+	for _, path := range []string{"/api/http/routers", "/api/tcp/routers"} {
+		listed, err := c.getAllPages(path)
+		if err != nil {
+			return nil, err
+		}
+		routers = append(routers, listed...)
+	}
+	return routers, nil
+}
+
+// getAllPages follows the X-Next-Page header Traefik sends with each page of routers
+func (c *Client) getAllPages(path string) ([]Router, error) {
+	var routers []Router
+	for page := 1; ; {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s%s?page=%d&per_page=100", c.url, path, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resBody, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != 200 {
+			return nil, fmt.Errorf("Failed to get %s from Traefik: %s", path, res.Status)
+		}
+		var content []Router
+		err = json.Unmarshal(resBody, &content)
+		if err != nil {
+			return nil, err
+		}
+		routers = append(routers, content...)
+
+		// Traefik points the last page back at page 1
+		next, err := strconv.Atoi(res.Header.Get("X-Next-Page"))
+		if err != nil || next <= page || len(content) == 0 {
+			break
+		}
+		page = next
+	}
+	return routers, nil
+}
+
+// matcherPattern finds Host and HostSNI matchers along with their arguments. HostRegexp and
+// HostSNIRegexp have no fixed names and are not matched.
+var matcherPattern = regexp.MustCompile(`\b(Host|HostSNI)\s*\(([^)]*)\)`)
+
+// argumentPattern finds the quoted arguments of a matcher, Traefik accepts backticks or double quotes
+var argumentPattern = regexp.MustCompile("`([^`]*)`|\"([^\"]*)\"")
+
+// Hosts returns the hostnames named by Host() and HostSNI() matchers in a router rule. Traefik v2
+// allows several names in one matcher, v3 one per matcher joined with ||, and both are handled.
+// The catch all HostSNI(`*`) is ignored.
+func (r Router) Hosts() []string {
+	var hosts []string
+	for _, matcher := range matcherPattern.FindAllStringSubmatch(r.Rule, -1) {
+		for _, argument := range argumentPattern.FindAllStringSubmatch(matcher[2], -1) {
+			host := strings.ToLower(strings.TrimSpace(argument[1] + argument[2]))
+			if host == "" || host == "*" {
+				continue
+			}
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// Enabled reports whether Traefik loaded the router, routers with warnings still serve traffic
+func (r Router) Enabled() bool {
+	return r.Status != "disabled"
+}
@@ -0,0 +1,73 @@
+package traefik
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHosts(t *testing.T) {
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"Host(`grafana.awesome.com`)", []string{"grafana.awesome.com"}},
+		{"Host(`a.awesome.com`, `B.awesome.com`) && PathPrefix(`/api`)", []string{"a.awesome.com", "b.awesome.com"}},
+		{"Host(\"a.awesome.com\") || Host(`c.awesome.com`)", []string{"a.awesome.com", "c.awesome.com"}},
+		{"HostSNI(`db.awesome.com`)", []string{"db.awesome.com"}},
+		{"HostSNI(`*`)", nil},
+		{"HostRegexp(`{sub:[a-z]+}.awesome.com`)", nil},
+		{"PathPrefix(`/`)", nil},
+	}
+	for _, test := range tests {
+		got := Router{Rule: test.rule}.Hosts()
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.rule, test.want, got)
+		}
+	}
+}
+
+func TestGetRouters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			t.Errorf("Expected basic auth, got %s %s", username, password)
+		}
+		var response []Router
+		switch r.URL.Path + "?" + r.URL.Query().Get("page") {
+		case "/api/http/routers?1":
+			w.Header().Set("X-Next-Page", "2")
+			response = []Router{{Name: "grafana@docker", Rule: "Host(`grafana.awesome.com`)"}}
+		case "/api/http/routers?2":
+			w.Header().Set("X-Next-Page", "1")
+			response = []Router{{Name: "old@file", Rule: "Host(`old.awesome.com`)", Status: "disabled"}}
+		case "/api/tcp/routers?1":
+			response = []Router{{Name: "db@docker", Rule: "HostSNI(`db.awesome.com`)"}}
+		default:
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		responseJson, err := json.Marshal(response)
+		if err != nil {
+			t.Errorf("Error marshalling response: %s", err)
+		}
+		w.WriteHeader(200)
+		w.Write(responseJson)
+	}))
+	defer server.Close()
+
+	routers, err := NewClient(server.URL, "user", "pass", nil).GetRouters()
+	if err != nil {
+		t.Fatalf("Error getting routers: %s", err)
+	}
+	if len(routers) != 3 {
+		t.Fatalf("Expected 3 routers, got %d", len(routers))
+	}
+	if routers[1].Enabled() {
+		t.Errorf("Expected %s to be disabled", routers[1].Name)
+	}
+	if routers[2].Name != "db@docker" {
+		t.Errorf("Expected the TCP router last, got %s", routers[2].Name)
+	}
+}
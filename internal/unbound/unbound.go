@@ -0,0 +1,72 @@
+package unbound
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Record is an A or AAAA record with a fully qualified name without the trailing dot. A name starting
+// with "*." answers for the name below it and everything under that.
+type Record struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// Ptr is a reverse record from an address to a name
+type Ptr struct {
+	Address string
+	Name    string
+}
+
+// Config is everything that goes in the include file
+type Config struct {
+	// Zone is a local-zone declared with ZoneType, such as the local suffix
+	Zone     string
+	ZoneType string
+	Records  []Record
+	Ptrs     []Ptr
+}
+
+// Render returns an include file for the server clause of unbound.conf. Wildcards become redirect
+// zones, as that is the only way unbound answers for every name below one. Everything is sorted so the
+// same records always give the same file.
+func Render(config Config) []byte {
+	zones := map[string]string{}
+	if config.Zone != "" {
+		zones[config.Zone] = config.ZoneType
+	}
+	var data []string
+	for _, record := range config.Records {
+		name := record.Name
+		if strings.HasPrefix(name, "*.") {
+			name = strings.TrimPrefix(name, "*.")
+			zones[name] = "redirect"
+		}
+		data = append(data, fmt.Sprintf("local-data: \"%s. IN %s %s\"", name, record.Type, record.Value))
+	}
+	slices.Sort(data)
+
+	ptrs := slices.Clone(config.Ptrs)
+	slices.SortFunc(ptrs, func(a Ptr, b Ptr) int {
+		return cmp.Or(cmp.Compare(a.Address, b.Address), cmp.Compare(a.Name, b.Name))
+	})
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "# Generated by unipidns, changes made here will be overwritten\n")
+	fmt.Fprintf(&buffer, "server:\n")
+	for _, zone := range slices.Sorted(maps.Keys(zones)) {
+		fmt.Fprintf(&buffer, "\tlocal-zone: \"%s.\" %s\n", zone, zones[zone])
+	}
+	for _, line := range slices.Compact(data) {
+		fmt.Fprintf(&buffer, "\t%s\n", line)
+	}
+	for _, ptr := range ptrs {
+		fmt.Fprintf(&buffer, "\tlocal-data-ptr: \"%s %s.\"\n", ptr.Address, ptr.Name)
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,39 @@
+package unbound
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	rendered := string(Render(Config{
+		Zone:     "lan",
+		ZoneType: "transparent",
+		Records: []Record{
+			{Name: "nas.lan", Type: "A", Value: "192.168.1.10"},
+			{Name: "files.awesome.com", Type: "A", Value: "192.168.1.2"},
+			{Name: "*.apps.awesome.com", Type: "A", Value: "192.168.1.2"},
+			{Name: "nas.lan", Type: "A", Value: "192.168.1.10"},
+		},
+		Ptrs: []Ptr{{Address: "192.168.1.10", Name: "nas.lan"}},
+	}))
+	want := `# Generated by unipidns, changes made here will be overwritten
+server:
+	local-zone: "apps.awesome.com." redirect
+	local-zone: "lan." transparent
+	local-data: "apps.awesome.com. IN A 192.168.1.2"
+	local-data: "files.awesome.com. IN A 192.168.1.2"
+	local-data: "nas.lan. IN A 192.168.1.10"
+	local-data-ptr: "192.168.1.10 nas.lan."
+`
+	if rendered != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, rendered)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	rendered := string(Render(Config{}))
+	if !strings.HasSuffix(rendered, "server:\n") {
+		t.Errorf("Expected just the server clause, got\n%s", rendered)
+	}
+}
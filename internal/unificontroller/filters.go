@@ -0,0 +1,144 @@
+package unificontroller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/unpoller/unifi"
+)
+
+// FilterConfig decides which fixed IP clients are published. When Include has rules a client must
+// match at least one of them, and a client matching any Exclude rule is always dropped.
+type FilterConfig struct {
+	Include []FilterRule `json:"include"`
+	Exclude []FilterRule `json:"exclude"`
+}
+
+// FilterRule matches a client when every field that is set matches.
+//   - Network is the name of the Unifi network the reservation is on
+//   - Vlan is the VLAN id of that network
+//   - Name is a regular expression tested against the Unifi name and the generated hostname
+//   - Mac is a MAC address prefix, such as an OUI like "b8:27:eb"
+//   - Tag is a word that must appear in the client note, such as "#nodns"
+type FilterRule struct {
+	Network string `json:"network"`
+	Vlan    int    `json:"vlan"`
+	Name    string `json:"name"`
+	Mac     string `json:"mac"`
+	Tag     string `json:"tag"`
+
+	name *regexp.Regexp
+}
+
+type network struct {
+	ID   string        `json:"_id"`
+	Name string        `json:"name"`
+	Vlan unifi.FlexInt `json:"vlan"`
+}
+
+// filterClient is what the rules are tested against
+type filterClient struct {
+	user
+	GeneratedName string
+	Network       network
+}
+
+// compile validates the rules and prepares their regular expressions
+func (f *FilterConfig) compile() error {
+	for _, rules := range [][]FilterRule{f.Include, f.Exclude} {
+		for idx, rule := range rules {
+			if rule.Name == "" {
+				continue
+			}
+			expression, err := regexp.Compile(rule.Name)
+			if err != nil {
+				return fmt.Errorf("invalid unifi filter name pattern %q: %w", rule.Name, err)
+			}
+			rules[idx].name = expression
+		}
+	}
+	return nil
+}
+
+// needsNetworks reports whether any rule requires the network list to be fetched
+func (f *FilterConfig) needsNetworks() bool {
+	for _, rules := range [][]FilterRule{f.Include, f.Exclude} {
+		for _, rule := range rules {
+			if rule.Network != "" || rule.Vlan != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *FilterConfig) allows(client filterClient) bool {
+	if len(f.Include) != 0 {
+		included := false
+		for _, rule := range f.Include {
+			if rule.matches(client) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, rule := range f.Exclude {
+		if rule.matches(client) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r FilterRule) matches(client filterClient) bool {
+	if r.Network != "" && !strings.EqualFold(r.Network, client.Network.Name) {
+		return false
+	}
+	if r.Vlan != 0 && r.Vlan != int(client.Network.Vlan.Val) {
+		return false
+	}
+	if r.name != nil && !r.name.MatchString(client.Name) && !r.name.MatchString(client.GeneratedName) {
+		return false
+	}
+	if r.Mac != "" && !strings.HasPrefix(normaliseMac(client.Mac), normaliseMac(r.Mac)) {
+		return false
+	}
+	if r.Tag != "" && !hasTag(client.Note, r.Tag) {
+		return false
+	}
+	return true
+}
+
+func normaliseMac(mac string) string {
+	return strings.ToLower(strings.ReplaceAll(mac, "-", ":"))
+}
+
+func hasTag(note string, tag string) bool {
+	for _, word := range strings.Fields(note) {
+		if strings.EqualFold(strings.Trim(word, ".,;"), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// getNetworks returns the networks configured on the site keyed by id
+func getNetworks(api legacyApi, siteName string) (map[string]network, error) {
+	fmt.Println("Fetching Networks")
+	var response struct {
+		Data []network `json:"data"`
+	}
+	err := api.GetData(fmt.Sprintf(unifi.APINetworkPath, siteName), &response)
+	if err != nil {
+		return nil, err
+	}
+	networks := map[string]network{}
+	for _, n := range response.Data {
+		networks[n.ID] = n
+	}
+	return networks, nil
+}
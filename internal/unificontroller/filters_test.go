@@ -0,0 +1,46 @@
+package unificontroller
+
+import (
+	"testing"
+
+	"github.com/unpoller/unifi"
+)
+
+func TestFilterAllows(t *testing.T) {
+	filters := &FilterConfig{
+		Include: []FilterRule{{Network: "LAN"}, {Vlan: 20}},
+		Exclude: []FilterRule{{Name: "(?i)^camera"}, {Mac: "B8-27-EB"}, {Tag: "#nodns"}},
+	}
+	if err := filters.compile(); err != nil {
+		t.Fatalf("Error compiling filters: %s", err)
+	}
+	lan := network{ID: "1", Name: "LAN"}
+	iot := network{ID: "2", Name: "IoT", Vlan: unifi.FlexInt{Val: 30}}
+	servers := network{ID: "3", Name: "Servers", Vlan: unifi.FlexInt{Val: 20}}
+
+	tests := []struct {
+		description string
+		client      filterClient
+		want        bool
+	}{
+		{"included network", filterClient{user: user{Name: "Desktop", Mac: "00:11:22:33:44:55"}, GeneratedName: "desktop", Network: lan}, true},
+		{"included vlan", filterClient{user: user{Name: "NAS", Mac: "00:11:22:33:44:56"}, GeneratedName: "nas", Network: servers}, true},
+		{"not included", filterClient{user: user{Name: "Plug", Mac: "00:11:22:33:44:57"}, GeneratedName: "plug", Network: iot}, false},
+		{"excluded name", filterClient{user: user{Name: "Camera Front", Mac: "00:11:22:33:44:58"}, GeneratedName: "camera-front", Network: lan}, false},
+		{"excluded generated name", filterClient{user: user{Name: "Doorbell", Note: "dns: camera-door", Mac: "00:11:22:33:44:59"}, GeneratedName: "camera-door", Network: lan}, false},
+		{"excluded oui", filterClient{user: user{Name: "Pi", Mac: "b8:27:eb:12:34:56"}, GeneratedName: "pi", Network: lan}, false},
+		{"excluded tag", filterClient{user: user{Name: "Laptop", Note: "Work laptop #NoDNS", Mac: "00:11:22:33:44:5a"}, GeneratedName: "laptop", Network: lan}, false},
+	}
+	for _, test := range tests {
+		if got := filters.allows(test.client); got != test.want {
+			t.Errorf("%s: expected %t, got %t", test.description, test.want, got)
+		}
+	}
+}
+
+func TestFilterInvalidPattern(t *testing.T) {
+	filters := &FilterConfig{Exclude: []FilterRule{{Name: "("}}}
+	if err := filters.compile(); err == nil {
+		t.Errorf("Expected an error for an invalid name pattern")
+	}
+}
@@ -0,0 +1,101 @@
+package unificontroller
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxLabelLength is the longest DNS label allowed by RFC 1035
+const maxLabelLength = 63
+
+// transliterations covers the common letters that do not decompose into an ASCII base letter
+var transliterations = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'đ': "d",
+	'ð': "d",
+	'ł': "l",
+	'þ': "th",
+	'ı': "i",
+}
+
+// hostnameLabel turns a Unifi client name into a single RFC 1123 hostname label.
+// Accented Latin letters are transliterated, other scripts are kept and encoded as punycode, and
+// everything else (spaces, punctuation, emoji) becomes a hyphen. Runs of hyphens are collapsed,
+// leading and trailing hyphens removed and the label is cut to 63 octets. It returns an empty string
+// when name has nothing usable in it.
+func hostnameLabel(name string) string {
+	var builder strings.Builder
+	lastAscii := false
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		isMark := unicode.Is(unicode.Mn, r)
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(r)
+		case isMark && lastAscii:
+			// accents left behind when a Latin letter is decomposed
+		case isMark:
+			// marks on other scripts are part of the letter and are recomposed below
+			builder.WriteRune(r)
+		case transliterations[r] != "":
+			builder.WriteString(transliterations[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune('-')
+		}
+		if !isMark {
+			lastAscii = r < unicode.MaxASCII
+		}
+	}
+
+	label := collapseHyphens(norm.NFC.String(builder.String()))
+	if isAscii(label) {
+		if len(label) > maxLabelLength {
+			label = strings.TrimRight(label[:maxLabelLength], "-")
+		}
+	} else {
+		label = punycode(label)
+	}
+	return label
+}
+
+// punycode encodes label as an IDNA A-label, dropping characters from the end until it fits in a label
+func punycode(label string) string {
+	runes := []rune(label)
+	for len(runes) > 0 {
+		encoded, err := idna.Punycode.ToASCII(strings.TrimRight(string(runes), "-"))
+		if err != nil {
+			return ""
+		}
+		if len(encoded) <= maxLabelLength {
+			return encoded
+		}
+		runes = runes[:len(runes)-1]
+	}
+	return ""
+}
+
+// macHostname builds a fallback label such as "client-b827eb123456"
+func macHostname(mac string) string {
+	return "client-" + strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
+
+func collapseHyphens(label string) string {
+	parts := strings.FieldsFunc(label, func(r rune) bool { return r == '-' })
+	return strings.Join(parts, "-")
+}
+
+func isAscii(s string) bool {
+	for _, r := range s {
+		if r >= unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
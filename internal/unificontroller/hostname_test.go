@@ -0,0 +1,56 @@
+package unificontroller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHostnameLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Amazon Echo", "amazon-echo"},
+		{"Chris's iPhone", "chris-s-iphone"},
+		{"Chris’ iPad Pro", "chris-ipad-pro"},
+		{"Living Room TV (LG)", "living-room-tv-lg"},
+		{"Kitchen/Dining Speaker", "kitchen-dining-speaker"},
+		{"Office.Printer", "office-printer"},
+		{"nas_01", "nas-01"},
+		{"  --Garage Door-- ", "garage-door"},
+		{"Café Büro", "cafe-buro"},
+		{"Straße Ærø", "strasse-aero"},
+		{"Łódź Camera", "lodz-camera"},
+		{"NAS 📦", "nas"},
+		{"🎮🎮", ""},
+		{"", ""},
+		{"192.168.1.50", "192-168-1-50"},
+		{"東京", "xn--1lqs71d"},
+		{"Wohnzimmer Fernseher", "wohnzimmer-fernseher"},
+		{"ﬁle server", "file-server"},
+	}
+	for _, test := range tests {
+		got := hostnameLabel(test.name)
+		if got != test.want {
+			t.Errorf("hostnameLabel(%q) = %q, expected %q", test.name, got, test.want)
+		}
+	}
+	if got := macHostname("B8:27:EB:12:34:56"); got != "client-b827eb123456" {
+		t.Errorf("Expected client-b827eb123456, got %s", got)
+	}
+}
+
+func TestHostnameLabelLength(t *testing.T) {
+	got := hostnameLabel(strings.Repeat("Very Long Device Name ", 5))
+	if len(got) > maxLabelLength {
+		t.Errorf("Expected at most %d characters, got %d (%s)", maxLabelLength, len(got), got)
+	}
+	if strings.HasSuffix(got, "-") {
+		t.Errorf("Expected no trailing hyphen, got %s", got)
+	}
+
+	got = hostnameLabel(strings.Repeat("東京", 40))
+	if len(got) > maxLabelLength || !strings.HasPrefix(got, "xn--") {
+		t.Errorf("Expected a punycode label of at most %d characters, got %s", maxLabelLength, got)
+	}
+}
@@ -0,0 +1,159 @@
+package unificontroller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const integrationPath = "/proxy/network/integration/v1"
+
+// errApiKeyRejected is returned when the controller refuses the API key for a request
+var errApiKeyRejected = errors.New("unifi API key was rejected")
+
+// apiKeyClient talks to a UniFi OS console using an API key instead of a login session
+type apiKeyClient struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+type integrationSite struct {
+	ID                string `json:"id"`
+	InternalReference string `json:"internalReference"`
+	Name              string `json:"name"`
+}
+
+type integrationClient struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	IpAddress  string `json:"ipAddress"`
+	MacAddress string `json:"macAddress"`
+}
+
+type integrationPage[T any] struct {
+	Offset     int `json:"offset"`
+	Limit      int `json:"limit"`
+	Count      int `json:"count"`
+	TotalCount int `json:"totalCount"`
+	Data       []T `json:"data"`
+}
+
+func newApiKeyClient(config *Config) (*apiKeyClient, error) {
+	tlsConfig, err := config.tlsClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &apiKeyClient{
+		url:    strings.TrimRight(config.Url, "/"),
+		apiKey: config.ApiKey,
+		client: &http.Client{
+			Timeout:   config.timeout(),
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// connectWithApiKey finds the target site using the integration API and returns the client, the short
+// name of the site for the classic API and the names the integration API reports for connected clients
+func connectWithApiKey(config *Config) (*apiKeyClient, string, map[string]string, error) {
+	client, err := newApiKeyClient(config)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	sites, err := getAllPages[integrationSite](client, integrationPath+"/sites")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	fmt.Println("The Following Sites have been found configured on the Unifi Controller:")
+	var targetSite *integrationSite
+	for idx, site := range sites {
+		fmt.Println(site.InternalReference)
+		fmt.Println(site.Name)
+		if strings.HasPrefix(site.Name, config.Site) {
+			targetSite = &sites[idx]
+		}
+	}
+	if targetSite == nil {
+		fmt.Println("The target site was not found - Unable to continue")
+		return nil, "", nil, errors.New("target site not found")
+	}
+	fmt.Println("Target Site Found")
+
+	clients, err := getAllPages[integrationClient](client, fmt.Sprintf("%s/sites/%s/clients", integrationPath, targetSite.ID))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	fmt.Printf("%d Connected Clients Reported\n", len(clients))
+	names := map[string]string{}
+	for _, c := range clients {
+		names[strings.ToLower(c.MacAddress)] = c.Name
+	}
+
+	return client, targetSite.InternalReference, names, nil
+}
+
+// getAllPages follows the offset/limit paging used by every integration list endpoint
+func getAllPages[T any](client *apiKeyClient, path string) ([]T, error) {
+	var items []T
+	for {
+		var page integrationPage[T]
+		err := client.do("GET", fmt.Sprintf("%s?offset=%d&limit=200", path, len(items)), "", &page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Data...)
+		if len(page.Data) == 0 || len(items) >= page.TotalCount {
+			return items, nil
+		}
+	}
+}
+
+// GetData requests a classic controller API path, the API key is accepted there as well as on the integration API
+func (c *apiKeyClient) GetData(apiPath string, v interface{}, params ...string) error {
+	verb := "GET"
+	body := strings.Join(params, " ")
+	if body != "" {
+		verb = "POST"
+	}
+	return c.do(verb, "/proxy/network"+apiPath, body, v)
+}
+
+func (c *apiKeyClient) do(verb string, path string, body string, v interface{}) error {
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequest(verb, c.url+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("X-API-KEY", c.apiKey)
+	req.Header.Add("Accept", "application/json")
+	if body != "" {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 401 || res.StatusCode == 403 {
+		return fmt.Errorf("%w: %s", errApiKeyRejected, res.Status)
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("failed to get %s from Unifi: %s", path, res.Status)
+	}
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resBody, v)
+}
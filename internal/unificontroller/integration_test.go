@@ -0,0 +1,117 @@
+package unificontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGetAllPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-KEY") != "key" {
+			t.Errorf("Expected X-API-KEY header key, got %s", r.Header.Get("X-API-KEY"))
+		}
+		offset := 0
+		fmt.Sscanf(r.URL.Query().Get("offset"), "%d", &offset)
+		page := integrationPage[integrationSite]{Offset: offset, TotalCount: 3}
+		if offset < 3 {
+			page.Data = []integrationSite{{ID: fmt.Sprintf("site-%d", offset), Name: "Default"}}
+			if offset == 0 {
+				page.Data = append(page.Data, integrationSite{ID: "site-1", Name: "Lab"})
+			}
+		}
+		page.Count = len(page.Data)
+
+		responseJson, err := json.Marshal(page)
+		if err != nil {
+			t.Errorf("Error marshalling response: %s", err)
+		}
+		w.WriteHeader(200)
+		w.Write(responseJson)
+	}))
+	defer server.Close()
+
+	client, err := newApiKeyClient(&Config{Url: server.URL, ApiKey: "key"})
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	sites, err := getAllPages[integrationSite](client, integrationPath+"/sites")
+	if err != nil {
+		t.Errorf("Error getting sites: %s", err)
+	}
+	if len(sites) != 3 {
+		t.Errorf("Expected 3 sites, got %d", len(sites))
+	}
+}
+
+// apiKeyServer answers the integration API and the classic stat/alluser request, which gets status and users
+func apiKeyServer(t *testing.T, status int, users string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case integrationPath + "/sites":
+			response = integrationPage[integrationSite]{TotalCount: 1, Data: []integrationSite{{ID: "site-id", InternalReference: "default", Name: "Default"}}}
+		case integrationPath + "/sites/site-id/clients":
+			response = integrationPage[integrationClient]{TotalCount: 2, Data: []integrationClient{
+				{Type: "WIRED", Name: "NAS", IpAddress: "192.168.1.10", MacAddress: "AA:BB:CC:DD:EE:01"},
+				{Type: "WIRELESS", Name: "Phone", IpAddress: "192.168.1.150", MacAddress: "aa:bb:cc:dd:ee:02"},
+			}}
+		case "/proxy/network/api/s/default/stat/alluser":
+			w.WriteHeader(status)
+			w.Write([]byte(users))
+			return
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(404)
+			return
+		}
+		responseJson, err := json.Marshal(response)
+		if err != nil {
+			t.Errorf("Error marshalling response: %s", err)
+		}
+		w.Write(responseJson)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestApiKeyReservations(t *testing.T) {
+	server := apiKeyServer(t, 200, `{"data": [
+		{"mac": "aa:bb:cc:dd:ee:01", "hostname": "nas-01", "use_fixedip": true, "fixed_ip": "192.168.1.10", "last_ip": "192.168.1.10"},
+		{"mac": "aa:bb:cc:dd:ee:02", "name": "Phone", "last_ip": "192.168.1.150"},
+		{"mac": "aa:bb:cc:dd:ee:03", "name": "Printer", "note": "#nodns", "use_fixedip": true, "fixed_ip": "192.168.1.20"},
+		{"mac": "aa:bb:cc:dd:ee:04", "name": "Camera", "use_fixedip": true, "fixed_ip": "192.168.1.30"}
+	]}`)
+	config := &Config{Url: server.URL, ApiKey: "key", Site: "Default", TLS: &TLSConfig{}, Filters: &FilterConfig{Exclude: []FilterRule{{Tag: "#nodns"}}}}
+	clients, _, err := GetFixedIpClients(config)
+	if err != nil {
+		t.Fatalf("Error getting clients: %s", err)
+	}
+	// the phone is connected but has no reservation, the printer is tagged #nodns and the camera is
+	// reserved while offline
+	var names []string
+	for _, client := range clients {
+		names = append(names, client.Name+" "+client.Ip)
+	}
+	expected := []string{"nas 192.168.1.10", "camera 192.168.1.30"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestApiKeyRejectedByClassicApi(t *testing.T) {
+	server := apiKeyServer(t, 401, `{"meta": {"rc": "error", "msg": "api.err.LoginRequired"}}`)
+	config := &Config{Url: server.URL, ApiKey: "key", Site: "Default", TLS: &TLSConfig{}}
+	clients, _, err := GetFixedIpClients(config)
+	if !errors.Is(err, errApiKeyRejected) || !strings.Contains(err.Error(), "IP reservations") {
+		t.Errorf("Expected an error about reading IP reservations with the key, got %v", err)
+	}
+	if len(clients) != 0 {
+		t.Errorf("Expected no clients, got %v", clients)
+	}
+}
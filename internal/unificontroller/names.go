@@ -0,0 +1,82 @@
+package unificontroller
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// NameConfig controls how the DNS name of a client is chosen.
+// Precedence lists the sources to try in order, the first one that produces a usable hostname wins:
+//   - alias: the first name in a `dns:` line in the client note
+//   - note: the client note, without any `dns:` line
+//   - name: the name given to the client in Unifi
+//   - hostname: the hostname the client sent with its DHCP request
+//
+// AliasMode is "cname" (the default) to publish the remaining `dns:` names as CNAMEs to the
+// primary name, or "host" to publish them as additional host records for the same addresses.
+type NameConfig struct {
+	Precedence []string `json:"precedence"`
+	AliasMode  string   `json:"aliasMode"`
+}
+
+var defaultNamePrecedence = []string{"alias", "note", "name", "hostname"}
+
+// aliasDirective matches a `dns: nas, files` line in a client note
+var aliasDirective = regexp.MustCompile(`(?im)^\s*dns:(.*)$`)
+
+// parseNote splits a client note into the free text and the names declared in its `dns:` lines
+func parseNote(note string) (string, []string) {
+	var aliases []string
+	for _, match := range aliasDirective.FindAllStringSubmatch(note, -1) {
+		for _, alias := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			aliases = append(aliases, alias)
+		}
+	}
+	text := strings.TrimSpace(aliasDirective.ReplaceAllString(note, ""))
+	return text, aliases
+}
+
+// clientNames picks the primary hostname for a client using precedence and returns it with any extra aliases from its note
+func clientNames(client user, precedence []string) (string, []string) {
+	note, declared := parseNote(client.Note)
+
+	var aliases []string
+	for _, alias := range declared {
+		if label := hostnameLabel(alias); label != "" {
+			aliases = append(aliases, label)
+		}
+	}
+
+	name := ""
+	for _, source := range precedence {
+		candidate := ""
+		switch source {
+		case "alias":
+			if len(aliases) != 0 {
+				candidate = aliases[0]
+			}
+		case "note":
+			candidate = hostnameLabel(note)
+		case "name":
+			candidate = hostnameLabel(client.Name)
+		case "hostname":
+			candidate = hostnameLabel(client.Hostname)
+		}
+		if candidate != "" {
+			name = candidate
+			break
+		}
+	}
+	if name == "" {
+		name = macHostname(client.Mac)
+	}
+
+	var extra []string
+	for _, alias := range aliases {
+		if alias != name && !slices.Contains(extra, alias) {
+			extra = append(extra, alias)
+		}
+	}
+	return name, extra
+}
@@ -0,0 +1,45 @@
+package unificontroller
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseNote(t *testing.T) {
+	note, aliases := parseNote("Loft NAS\ndns: nas, files  backup")
+	if note != "Loft NAS" {
+		t.Errorf("Expected note Loft NAS, got %q", note)
+	}
+	if !slices.Equal(aliases, []string{"nas", "files", "backup"}) {
+		t.Errorf("Expected aliases nas, files, backup, got %v", aliases)
+	}
+
+	note, aliases = parseNote("DNS:grafana")
+	if note != "" || !slices.Equal(aliases, []string{"grafana"}) {
+		t.Errorf("Expected only alias grafana, got note %q and aliases %v", note, aliases)
+	}
+}
+
+func TestClientNames(t *testing.T) {
+	client := user{Name: "Synology", Hostname: "DS920", Note: "Loft NAS\ndns: nas, files, nas", Mac: "00:11:32:aa:bb:cc"}
+
+	name, aliases := clientNames(client, defaultNamePrecedence)
+	if name != "nas" || !slices.Equal(aliases, []string{"files"}) {
+		t.Errorf("Expected nas with alias files, got %s with %v", name, aliases)
+	}
+
+	name, aliases = clientNames(client, []string{"hostname", "name"})
+	if name != "ds920" || !slices.Equal(aliases, []string{"nas", "files"}) {
+		t.Errorf("Expected ds920 with aliases nas and files, got %s with %v", name, aliases)
+	}
+
+	name, _ = clientNames(user{Hostname: "esp-1234", Mac: "00:11:32:aa:bb:cc"}, defaultNamePrecedence)
+	if name != "esp-1234" {
+		t.Errorf("Expected hostname to be used when nothing else is set, got %s", name)
+	}
+
+	name, _ = clientNames(user{Name: "🎮", Mac: "00:11:32:aa:bb:cc"}, []string{"name"})
+	if name != "client-001132aabbcc" {
+		t.Errorf("Expected MAC derived name, got %s", name)
+	}
+}
@@ -0,0 +1,48 @@
+package unificontroller
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Record is a DNS record defined directly in Unifi rather than derived from a client
+type Record struct {
+	Type  string
+	Name  string
+	Value string
+}
+
+// staticDnsEntry is an entry from Settings > Routing > DNS on UniFi Network 8.2 and later
+type staticDnsEntry struct {
+	ID         string `json:"_id"`
+	Key        string `json:"key"`
+	RecordType string `json:"record_type"`
+	Value      string `json:"value"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// getStaticDns returns the enabled A, AAAA and CNAME static DNS entries for the site, other types are ignored
+func getStaticDns(api legacyApi, siteName string) ([]Record, error) {
+	fmt.Println("Fetching Static DNS Entries")
+	var entries []staticDnsEntry
+	err := api.GetData(fmt.Sprintf("/v2/api/site/%s/static-dns", siteName), &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, entry := range entries {
+		recordType := strings.ToUpper(entry.RecordType)
+		if !entry.Enabled || entry.Key == "" || entry.Value == "" {
+			continue
+		}
+		switch recordType {
+		case "A", "AAAA", "CNAME":
+			records = append(records, Record{Type: recordType, Name: strings.ToLower(entry.Key), Value: strings.ToLower(entry.Value)})
+		default:
+			fmt.Printf("Skipping unsupported %s static DNS entry %s\n", recordType, entry.Key)
+		}
+	}
+	fmt.Printf("%d Static DNS Entries Found\n", len(records))
+	return records, nil
+}
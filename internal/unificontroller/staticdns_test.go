@@ -0,0 +1,37 @@
+package unificontroller
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// fakeApi serves canned JSON from the classic API paths
+type fakeApi map[string]string
+
+func (f fakeApi) GetData(apiPath string, v interface{}, params ...string) error {
+	return json.Unmarshal([]byte(f[apiPath]), v)
+}
+
+func TestGetStaticDns(t *testing.T) {
+	api := fakeApi{"/v2/api/site/default/static-dns": `[
+		{"_id": "1", "key": "Printer.lan", "record_type": "A", "value": "10.0.0.20", "enabled": true},
+		{"_id": "2", "key": "printer.lan", "record_type": "AAAA", "value": "fd00::20", "enabled": true},
+		{"_id": "3", "key": "print.lan", "record_type": "CNAME", "value": "printer.lan", "enabled": true},
+		{"_id": "4", "key": "old.lan", "record_type": "A", "value": "10.0.0.21", "enabled": false},
+		{"_id": "5", "key": "lan", "record_type": "TXT", "value": "hello", "enabled": true}
+	]`}
+
+	records, err := getStaticDns(api, "default")
+	if err != nil {
+		t.Fatalf("Error getting static DNS: %s", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+	if records[0] != (Record{Type: "A", Name: "printer.lan", Value: "10.0.0.20"}) {
+		t.Errorf("Expected lower case A record for printer.lan, got %v", records[0])
+	}
+	if records[2].Type != "CNAME" || records[2].Value != "printer.lan" {
+		t.Errorf("Expected CNAME to printer.lan, got %v", records[2])
+	}
+}
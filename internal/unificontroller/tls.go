@@ -0,0 +1,124 @@
+package unificontroller
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	urlProcessor "net/url"
+	"os"
+	"strings"
+	"time"
+	"unipidns/internal/httpclient"
+)
+
+// TLSConfig controls how the controller certificate is checked. With nothing set the system
+// trust store is used. Without a TLSConfig at all nothing is checked, as before it existed, and a
+// warning is printed until one is added.
+//   - CaFile is a PEM bundle of CAs to trust instead of the system store
+//   - Fingerprint pins the SHA-256 fingerprint of the controller certificate, colons are optional
+//   - Insecure turns verification off entirely
+type TLSConfig struct {
+	CaFile      string `json:"caFile"`
+	Fingerprint string `json:"fingerprint"`
+	Insecure    bool   `json:"insecure"`
+}
+
+func (c *Config) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return time.Duration(c.Timeout) * time.Second
+	}
+	return httpclient.DefaultTimeout
+}
+
+// tlsClientConfig builds the TLS settings for requests made directly to the controller
+func (c *Config) tlsClientConfig() (*tls.Config, error) {
+	settings := c.TLS
+	if settings == nil {
+		warnInsecure()
+		fmt.Println("There is no tls block in the unifi config. The next release will check the")
+		fmt.Println("certificate by default, add a tls block now to choose how it is checked.")
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	if settings.Insecure {
+		warnInsecure()
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if settings.CaFile != "" {
+		bundle, err := os.ReadFile(settings.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in unifi CA file %s", settings.CaFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if settings.Fingerprint != "" {
+		expected, err := parseFingerprint(settings.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		// the pinned certificate replaces chain verification, which a self signed certificate would fail
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) != 0 && sha256.Sum256(rawCerts[0]) == expected {
+				return nil
+			}
+			return errors.New("unifi controller certificate does not match the configured fingerprint")
+		}
+	}
+	return tlsConfig, nil
+}
+
+// pinnedCertificate connects to the controller using tlsConfig and returns the certificate it
+// presents as PEM, so the unifi library can pin it for the rest of the session
+func pinnedCertificate(url string, tlsConfig *tls.Config, timeout time.Duration) ([]byte, error) {
+	parsed, err := urlProcessor.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "443")
+	}
+	config := tlsConfig.Clone()
+	config.ServerName = parsed.Hostname()
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", host, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify unifi controller certificate: %w", err)
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errors.New("unifi controller did not present a certificate")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw}), nil
+}
+
+func parseFingerprint(fingerprint string) ([sha256.Size]byte, error) {
+	var expected [sha256.Size]byte
+	raw, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+	if err != nil || len(raw) != sha256.Size {
+		return expected, fmt.Errorf("unifi fingerprint %q is not a SHA-256 fingerprint", fingerprint)
+	}
+	copy(expected[:], raw)
+	return expected, nil
+}
+
+func warnInsecure() {
+	fmt.Println("****************************************************************")
+	fmt.Println("WARNING: TLS certificate verification for the Unifi controller")
+	fmt.Println("is DISABLED. Anyone able to intercept traffic to the controller")
+	fmt.Println("can read your credentials. Set tls.caFile or tls.fingerprint")
+	fmt.Println("in the unifi config instead of tls.insecure.")
+	fmt.Println("****************************************************************")
+}
@@ -0,0 +1,97 @@
+package unificontroller
+
+import (
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTLSTestServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(`{"totalCount": 0, "data": []}`))
+	}))
+}
+
+func TestFingerprintPinning(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+	// formatted the way openssl x509 -fingerprint -sha256 prints it
+	fingerprint := strings.ReplaceAll(fmt.Sprintf("% X", sha256.Sum256(server.Certificate().Raw)), " ", ":")
+
+	client, err := newApiKeyClient(&Config{Url: server.URL, ApiKey: "key", TLS: &TLSConfig{Fingerprint: fingerprint}})
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	if _, err := getAllPages[integrationSite](client, integrationPath+"/sites"); err != nil {
+		t.Errorf("Expected pinned certificate to be accepted, got %s", err)
+	}
+
+	client, err = newApiKeyClient(&Config{Url: server.URL, ApiKey: "key", TLS: &TLSConfig{Fingerprint: fmt.Sprintf("%x", sha256.Sum256([]byte("other")))}})
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	if _, err := getAllPages[integrationSite](client, integrationPath+"/sites"); err == nil {
+		t.Errorf("Expected a mismatched fingerprint to be rejected")
+	}
+}
+
+func TestCaFile(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+	if err != nil {
+		t.Fatalf("Error writing CA file: %s", err)
+	}
+	config := &Config{Url: server.URL, ApiKey: "key", TLS: &TLSConfig{CaFile: caFile}}
+
+	client, err := newApiKeyClient(config)
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	if _, err := getAllPages[integrationSite](client, integrationPath+"/sites"); err != nil {
+		t.Errorf("Expected certificate signed by the CA file to be accepted, got %s", err)
+	}
+
+	tlsConfig, err := config.tlsClientConfig()
+	if err != nil {
+		t.Fatalf("Error building TLS config: %s", err)
+	}
+	cert, err := pinnedCertificate(server.URL, tlsConfig, config.timeout())
+	if err != nil {
+		t.Fatalf("Error fetching certificate: %s", err)
+	}
+	block, _ := pem.Decode(cert)
+	if block == nil || sha256.Sum256(block.Bytes) != sha256.Sum256(server.Certificate().Raw) {
+		t.Errorf("Expected the server certificate to be pinned")
+	}
+}
+
+func TestDefaultVerification(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+
+	client, err := newApiKeyClient(&Config{Url: server.URL, ApiKey: "key", TLS: &TLSConfig{}})
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	if _, err := getAllPages[integrationSite](client, integrationPath+"/sites"); err == nil {
+		t.Errorf("Expected an untrusted certificate to be rejected by default")
+	}
+
+	// configs from before the tls block existed keep working until the next release
+	client, err = newApiKeyClient(&Config{Url: server.URL, ApiKey: "key"})
+	if err != nil {
+		t.Fatalf("Error creating client: %s", err)
+	}
+	if _, err := getAllPages[integrationSite](client, integrationPath+"/sites"); err != nil {
+		t.Errorf("Expected no verification without a tls block, got %s", err)
+	}
+}
@@ -3,67 +3,312 @@ package unificontroller
 import (
 	"errors"
 	"fmt"
-	"regexp"
+	"net"
+	"net/netip"
 	"strings"
 
 	"github.com/unpoller/unifi"
 )
 
+// Config selects how to reach the controller. When ApiKey is set the UniFi OS API key is used
+// against the Network integration API and Username/Password are ignored.
+type Config struct {
+	Username string        `json:"username"`
+	Password string        `json:"password"`
+	ApiKey   string        `json:"apiKey"`
+	Url      string        `json:"url"`
+	Site     string        `json:"site"`
+	IPv6     *IPv6Config   `json:"ipv6"`
+	Names    *NameConfig   `json:"names"`
+	Filters  *FilterConfig `json:"filters"`
+	TLS      *TLSConfig    `json:"tls"`
+	// Timeout is the request timeout in seconds, 30 when not set
+	Timeout int `json:"timeout"`
+	// ImportDns adds the static DNS entries and per-client local DNS records defined in Unifi
+	ImportDns bool `json:"importDns"`
+}
+
+// IPv6Config controls which of the IPv6 addresses reported by the controller are published.
+// Scope is one of "ula", "global" or "all" (the default).
+// ExcludeTemporary keeps only addresses whose interface identifier is derived from the
+// client MAC (EUI-64), as Unifi does not flag temporary or privacy addresses itself.
+type IPv6Config struct {
+	Enabled          bool   `json:"enabled"`
+	Scope            string `json:"scope"`
+	ExcludeTemporary bool   `json:"excludeTemporary"`
+}
+
 type Client struct {
-	Name string
-	Ip   string
+	Name    string
+	Aliases []string
+	Mac     string
+	Ip      string
+	Ipv6    []string
+	// LocalDnsRecord is the fully qualified name set as the client's local DNS record in Unifi, when imported
+	LocalDnsRecord string
+	// Fixed is false for clients without an IP reservation, which are only returned by GetAllClients
+	Fixed bool
+}
+
+// user is the subset of the stat/alluser response used to build records
+type user struct {
+	Name       string         `json:"name"`
+	Hostname   string         `json:"hostname"`
+	Note       string         `json:"note"`
+	Mac        string         `json:"mac"`
+	NetworkId  string         `json:"network_id"`
+	FixedIp    string         `json:"fixed_ip"`
+	LastIp     string         `json:"last_ip"`
+	UseFixedIp unifi.FlexBool `json:"use_fixedip"`
+
+	LocalDnsRecord        string         `json:"local_dns_record"`
+	LocalDnsRecordEnabled unifi.FlexBool `json:"local_dns_record_enabled"`
+}
+
+// activeClient is the subset of the stat/sta response needed for IPv6, which the unifi library does not decode
+type activeClient struct {
+	Mac           string   `json:"mac"`
+	Ipv6Addresses []string `json:"ipv6_addresses"`
+}
+
+// legacyApi is implemented by both *unifi.Unifi and apiKeyClient so the classic site API can be used with either
+type legacyApi interface {
+	GetData(apiPath string, v interface{}, params ...string) error
 }
 
-func GetFixedIpClients(username string, password string, url string, siteName string) ([]Client, error) {
+// GetFixedIpClients returns the fixed IP clients on the configured site, along with the static DNS
+// entries defined in Unifi when ImportDns is set
+func GetFixedIpClients(config *Config) ([]Client, []Record, error) {
+	return getClients(config, false)
+}
+
+// GetAllClients returns every client the site has seen, for auditing. Clients without a fixed IP
+// have Fixed set to false and Ip set to the address they last used, and filters are not applied.
+func GetAllClients(config *Config) ([]Client, []Record, error) {
+	return getClients(config, true)
+}
+
+func getClients(config *Config, includeDynamic bool) ([]Client, []Record, error) {
 	fmt.Println("Fetching Unifi Clients")
 
+	filters := config.Filters
+	if filters == nil {
+		filters = &FilterConfig{}
+	}
+	err := filters.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var api legacyApi
+	var siteName string
+	var clients []user
+	if config.ApiKey != "" {
+		var integrationNames map[string]string
+		api, siteName, integrationNames, err = connectWithApiKey(config)
+		if err == nil {
+			clients, err = getUsers(api, siteName)
+		}
+		if errors.Is(err, errApiKeyRejected) {
+			// the integration API has no IP reservations, so publishing its connected clients instead would be wrong
+			err = fmt.Errorf("failed to read IP reservations from the classic site API with the API key, use a username and password with this controller instead: %w", err)
+		}
+		for idx, client := range clients {
+			// Name is blank for clients that have never been renamed, the integration API reports a display name for them
+			if strings.TrimSpace(client.Name) == "" {
+				clients[idx].Name = integrationNames[strings.ToLower(client.Mac)]
+			}
+		}
+	} else {
+		api, siteName, err = connectWithLogin(config)
+		if err == nil {
+			clients, err = getUsers(api, siteName)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Printf("%d Clients Fetched\n", len(clients))
+
+	ipv6Addresses := map[string][]string{}
+	if config.IPv6 != nil && config.IPv6.Enabled {
+		ipv6Addresses, err = getIpv6Addresses(api, siteName, config.IPv6)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	networks := map[string]network{}
+	if filters.needsNetworks() {
+		networks, err = getNetworks(api, siteName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	precedence := defaultNamePrecedence
+	if config.Names != nil && len(config.Names.Precedence) != 0 {
+		precedence = config.Names.Precedence
+	}
+
+	var fixedIps []Client
+	filtered := 0
+	for _, client := range clients {
+		mac := strings.ToLower(client.Mac)
+		name, aliases := clientNames(client, precedence)
+		if !client.UseFixedIp.Val {
+			if includeDynamic && client.LastIp != "" {
+				fixedIps = append(fixedIps, Client{Name: name, Aliases: aliases, Mac: mac, Ip: client.LastIp, Ipv6: ipv6Addresses[mac]})
+			}
+			continue
+		}
+		if !includeDynamic && !filters.allows(filterClient{user: client, GeneratedName: name, Network: networks[client.NetworkId]}) {
+			filtered++
+			continue
+		}
+		fixedIp := Client{Name: name, Aliases: aliases, Mac: mac, Ip: client.FixedIp, Ipv6: ipv6Addresses[mac], Fixed: true}
+		if config.ImportDns && client.LocalDnsRecordEnabled.Val {
+			fixedIp.LocalDnsRecord = strings.ToLower(strings.TrimSpace(client.LocalDnsRecord))
+		}
+		fixedIps = append(fixedIps, fixedIp)
+	}
+	if !includeDynamic {
+		fmt.Printf("%d Fixed IP Clients Found\n", len(fixedIps))
+	}
+	if filtered != 0 {
+		fmt.Printf("%d Fixed IP Clients Filtered Out\n", filtered)
+	}
+
+	var records []Record
+	if config.ImportDns {
+		records, err = getStaticDns(api, siteName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return fixedIps, records, nil
+}
+
+// connectWithLogin logs in with a local admin account and returns the client along with the short name of the target site
+func connectWithLogin(config *Config) (legacyApi, string, error) {
 	unifiConfig := &unifi.Config{
-		User: username,
-		Pass: password,
-		URL:  url,
+		User:    config.Username,
+		Pass:    config.Password,
+		URL:     config.Url,
+		Timeout: config.timeout(),
 	}
 
-	invalidChars := regexp.MustCompile(`\s|'|:|,|_|’`)
+	tlsConfig, err := config.tlsClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	unifiConfig.VerifySSL = config.TLS != nil && !config.TLS.Insecure
+	if tlsConfig.RootCAs != nil || tlsConfig.VerifyPeerCertificate != nil {
+		// the library can only pin certificates, so check the certificate ourselves and pin the one we trust
+		cert, err := pinnedCertificate(config.Url, tlsConfig, config.timeout())
+		if err != nil {
+			return nil, "", err
+		}
+		unifiConfig.SSLCert = [][]byte{cert}
+	}
 
 	uClient, err := unifi.NewUnifi(unifiConfig)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	sites, err := uClient.GetSites()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	fmt.Println("The Following Sites have been found configured on the Unifi Controller:")
 	var targetSite *unifi.Site
 	for _, site := range sites {
 		fmt.Println(site.Name)
 		fmt.Println(site.SiteName)
-		if strings.HasPrefix(site.SiteName, siteName) {
+		if strings.HasPrefix(site.SiteName, config.Site) {
 			targetSite = site
 		}
 	}
 	if targetSite == nil {
 		fmt.Println("The target site was not found - Unable to continue")
-		return nil, errors.New("target site not found")
+		return nil, "", errors.New("target site not found")
 	}
 	fmt.Println("Target Site Found")
+	return uClient, targetSite.Name, nil
+}
+
+// getUsers returns every client the site has seen from the classic API
+func getUsers(api legacyApi, siteName string) ([]user, error) {
 	fmt.Println("Fetching Clients")
-	clients, err := uClient.GetUsers([]*unifi.Site{targetSite}, 87600)
+	var response struct {
+		Data []user `json:"data"`
+	}
+	err := api.GetData(fmt.Sprintf(unifi.APIAllUserPath, siteName), &response, `{ "type": "all:", "conn": "all", "within":87600 }`)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("%d Clients Fetched\n", len(clients))
-	var fixedIps []Client
-	for _, client := range clients {
-		if client.UseFixedIp.Val {
-			name := strings.ToLower(client.Name)
-			if len(client.Note) != 0 {
-				name = strings.ToLower(client.Note)
+	return response.Data, nil
+}
+
+// getIpv6Addresses returns the filtered IPv6 addresses of the currently connected clients keyed by MAC address
+func getIpv6Addresses(api legacyApi, siteName string, config *IPv6Config) (map[string][]string, error) {
+	fmt.Println("Fetching IPv6 Addresses")
+	var response struct {
+		Data []activeClient `json:"data"`
+	}
+	err := api.GetData(fmt.Sprintf(unifi.APIClientPath, siteName), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := map[string][]string{}
+	for _, client := range response.Data {
+		mac := strings.ToLower(client.Mac)
+		for _, address := range client.Ipv6Addresses {
+			ip, err := netip.ParseAddr(address)
+			if err != nil || !ip.Is6() || ip.Is4In6() {
+				continue
+			}
+			if !ipv6InScope(ip, config.Scope) {
+				continue
+			}
+			if config.ExcludeTemporary && !isEui64(ip, mac) {
+				continue
 			}
-			name = invalidChars.ReplaceAllString(name, "-")
-			fixedIps = append(fixedIps, Client{Name: name, Ip: client.FixedIp})
+			addresses[mac] = append(addresses[mac], ip.String())
+		}
+	}
+	return addresses, nil
+}
+
+var ulaPrefix = netip.MustParsePrefix("fc00::/7")
+
+func ipv6InScope(ip netip.Addr, scope string) bool {
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+	switch scope {
+	case "ula":
+		return ulaPrefix.Contains(ip)
+	case "global":
+		return !ulaPrefix.Contains(ip)
+	default:
+		return true
+	}
+}
+
+// isEui64 reports whether the interface identifier of ip was generated from mac (RFC 4291 appendix A)
+func isEui64(ip netip.Addr, mac string) bool {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return false
+	}
+	b := ip.As16()
+	iid := []byte{hw[0] ^ 0x02, hw[1], hw[2], 0xff, 0xfe, hw[3], hw[4], hw[5]}
+	for i, v := range iid {
+		if b[8+i] != v {
+			return false
 		}
 	}
-	fmt.Printf("%d Fixed IP Clients Found\n", len(fixedIps))
-	return fixedIps, nil
+	return true
 }
@@ -0,0 +1,39 @@
+package unificontroller
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIpv6InScope(t *testing.T) {
+	tests := []struct {
+		address string
+		scope   string
+		want    bool
+	}{
+		{"fd12:3456:789a::10", "ula", true},
+		{"fd12:3456:789a::10", "global", false},
+		{"fd12:3456:789a::10", "all", true},
+		{"2001:db8::10", "ula", false},
+		{"2001:db8::10", "global", true},
+		{"2001:db8::10", "", true},
+		{"fe80::1", "all", false},
+		{"ff02::1", "all", false},
+	}
+	for _, test := range tests {
+		got := ipv6InScope(netip.MustParseAddr(test.address), test.scope)
+		if got != test.want {
+			t.Errorf("ipv6InScope(%s, %q) = %t, expected %t", test.address, test.scope, got, test.want)
+		}
+	}
+}
+
+func TestIsEui64(t *testing.T) {
+	mac := "b8:27:eb:12:34:56"
+	if !isEui64(netip.MustParseAddr("fd00::ba27:ebff:fe12:3456"), mac) {
+		t.Errorf("Expected EUI-64 address to match %s", mac)
+	}
+	if isEui64(netip.MustParseAddr("fd00::1c2d:3e4f:5a6b:7c8d"), mac) {
+		t.Errorf("Expected random address not to match %s", mac)
+	}
+}
@@ -0,0 +1,118 @@
+package zonefile
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unipidns/internal/managedfile"
+)
+
+// Zone is everything that goes in a zone file
+type Zone struct {
+	// Origin is the zone name without the trailing dot
+	Origin string
+	// Nameserver is the name of the primary server, used for the SOA and the only NS record
+	Nameserver string
+	// NameserverAddress is the glue A or AAAA record for a Nameserver inside the zone
+	NameserverAddress string
+	// Hostmaster is the contact in SOA form, hostmaster.<origin> when empty
+	Hostmaster string
+	TTL        uint32
+	Records    []Record
+}
+
+// Record is an A, AAAA, CNAME or PTR record with fully qualified names without the trailing dot
+type Record struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// serialPattern finds the serial in a file written by Render
+var serialPattern = regexp.MustCompile(`(?m)^\s*(\d+)\s*; serial$`)
+
+// Render returns the zone file for zone with serial. Records are sorted so the same records always
+// give the same file.
+func Render(zone Zone, serial uint32) []byte {
+	hostmaster := zone.Hostmaster
+	if hostmaster == "" {
+		hostmaster = "hostmaster." + zone.Origin
+	}
+	records := slices.Clone(zone.Records)
+	if zone.NameserverAddress != "" && (zone.Nameserver == zone.Origin || strings.HasSuffix(zone.Nameserver, "."+zone.Origin)) {
+		glue := Record{Name: zone.Nameserver, Type: "A", Value: zone.NameserverAddress}
+		if strings.Contains(zone.NameserverAddress, ":") {
+			glue.Type = "AAAA"
+		}
+		if !slices.Contains(records, glue) {
+			records = append(records, glue)
+		}
+	}
+	slices.SortFunc(records, func(a Record, b Record) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type), cmp.Compare(a.Value, b.Value))
+	})
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "; Generated by unipidns, changes made here will be overwritten\n")
+	fmt.Fprintf(&buffer, "$ORIGIN %s.\n", zone.Origin)
+	fmt.Fprintf(&buffer, "$TTL %d\n", zone.TTL)
+	fmt.Fprintf(&buffer, "@\tIN\tSOA\t%s. %s. (\n", zone.Nameserver, hostmaster)
+	fmt.Fprintf(&buffer, "\t\t%d ; serial\n", serial)
+	fmt.Fprintf(&buffer, "\t\t3600 ; refresh\n")
+	fmt.Fprintf(&buffer, "\t\t600 ; retry\n")
+	fmt.Fprintf(&buffer, "\t\t604800 ; expire\n")
+	fmt.Fprintf(&buffer, "\t\t300 ) ; negative caching\n")
+	fmt.Fprintf(&buffer, "@\tIN\tNS\t%s.\n", zone.Nameserver)
+	for _, record := range records {
+		value := record.Value
+		if record.Type == "CNAME" || record.Type == "PTR" {
+			value += "."
+		}
+		fmt.Fprintf(&buffer, "%s.\tIN\t%s\t%s\n", record.Name, record.Type, value)
+	}
+	return buffer.Bytes()
+}
+
+// Write renders zone to path, keeping the serial of the existing file when nothing else has changed.
+// Otherwise the serial moves on to the next one in the YYYYMMDDnn form, and the file is replaced
+// atomically. It returns whether the file changed, and with dryRun set only works that out.
+func Write(path string, zone Zone, now time.Time, dryRun bool) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	var serial uint32
+	if match := serialPattern.FindSubmatch(existing); match != nil {
+		parsed, err := strconv.ParseUint(string(match[1]), 10, 32)
+		if err == nil {
+			serial = uint32(parsed)
+		}
+		if bytes.Equal(Render(zone, serial), existing) {
+			return false, nil
+		}
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, managedfile.Replace(path, Render(zone, nextSerial(serial, now)))
+}
+
+// nextSerial returns the serial after current, using today's date while there are changes left in it
+func nextSerial(current uint32, now time.Time) uint32 {
+	today, _ := strconv.ParseUint(now.Format("20060102")+"00", 10, 32)
+	if uint32(today) > current {
+		return uint32(today)
+	}
+	return current + 1
+}
+
+// FileName is the file a zone is written to, such as lan.zone
+func FileName(origin string) string {
+	return strings.TrimSuffix(origin, ".") + ".zone"
+}
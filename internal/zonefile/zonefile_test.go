@@ -0,0 +1,109 @@
+package zonefile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testZone = Zone{
+	Origin:     "lan",
+	Nameserver: "ns.lan",
+	TTL:        3600,
+	Records: []Record{
+		{Name: "nas.lan", Type: "A", Value: "192.168.1.10"},
+		{Name: "files.lan", Type: "CNAME", Value: "nas.lan"},
+	},
+}
+
+func TestRender(t *testing.T) {
+	rendered := string(Render(testZone, 2026101900))
+	for _, line := range []string{
+		"$ORIGIN lan.",
+		"@\tIN\tSOA\tns.lan. hostmaster.lan. (",
+		"\t\t2026101900 ; serial",
+		"files.lan.\tIN\tCNAME\tnas.lan.",
+		"nas.lan.\tIN\tA\t192.168.1.10",
+	} {
+		if !strings.Contains(rendered, line+"\n") {
+			t.Errorf("Expected %q in\n%s", line, rendered)
+		}
+	}
+	if strings.Index(rendered, "files.lan.") > strings.Index(rendered, "nas.lan.\tIN") {
+		t.Errorf("Expected records to be sorted by name")
+	}
+}
+
+func TestRenderGlue(t *testing.T) {
+	zone := testZone
+	zone.NameserverAddress = "192.168.1.2"
+	rendered := string(Render(zone, 1))
+	if strings.Count(rendered, "ns.lan.\tIN\tA\t192.168.1.2\n") != 1 {
+		t.Errorf("Expected one glue record for ns.lan in\n%s", rendered)
+	}
+
+	zone.Records = append(zone.Records, Record{Name: "ns.lan", Type: "A", Value: "192.168.1.2"})
+	if rendered := string(Render(zone, 1)); strings.Count(rendered, "ns.lan.\tIN\tA") != 1 {
+		t.Errorf("Expected the glue record not to repeat a desired record in\n%s", rendered)
+	}
+
+	zone = testZone
+	zone.Origin, zone.NameserverAddress = "1.168.192.in-addr.arpa", "fd00::2"
+	if rendered := string(Render(zone, 1)); strings.Contains(rendered, "ns.lan.\tIN\tAAAA") {
+		t.Errorf("Expected no glue for a nameserver outside the zone in\n%s", rendered)
+	}
+}
+
+func TestWriteSerial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName("lan"))
+	day := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	changed, err := Write(path, testZone, day, false)
+	if err != nil || !changed {
+		t.Fatalf("Expected the first write to change the file, got %t %v", changed, err)
+	}
+	changed, err = Write(path, testZone, day, false)
+	if err != nil || changed {
+		t.Errorf("Expected no change for the same records, got %t %v", changed, err)
+	}
+
+	changedZone := testZone
+	changedZone.Records = append(changedZone.Records, Record{Name: "printer.lan", Type: "A", Value: "192.168.1.20"})
+	changed, err = Write(path, changedZone, day, true)
+	if err != nil || !changed {
+		t.Errorf("Expected a dry run to report a change, got %t %v", changed, err)
+	}
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), "printer.lan") {
+		t.Errorf("Expected a dry run not to write the file")
+	}
+
+	changed, err = Write(path, changedZone, day, false)
+	if err != nil || !changed {
+		t.Fatalf("Expected the new record to change the file, got %t %v", changed, err)
+	}
+	content, _ = os.ReadFile(path)
+	if !strings.Contains(string(content), "2026101901 ; serial") {
+		t.Errorf("Expected the serial to increment, got\n%s", content)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left behind, got %d files", len(entries))
+	}
+}
+
+func TestNextSerial(t *testing.T) {
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if serial := nextSerial(2025010105, day); serial != 2026101900 {
+		t.Errorf("Expected today's first serial, got %d", serial)
+	}
+	if serial := nextSerial(2026101999, day); serial != 2026102000 {
+		t.Errorf("Expected the serial to keep increasing, got %d", serial)
+	}
+	if serial := nextSerial(7, day); serial != 2026101900 {
+		t.Errorf("Expected a counter serial to move to the date form, got %d", serial)
+	}
+}
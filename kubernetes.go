@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"unipidns/internal/kubernetes"
+)
+
+// addKubernetesRecords adds the Ingress and HTTPRoute hosts of every cluster to edges, as CNAMEs to the
+// configured ingress name or as host records for the load balancer address
+func addKubernetesRecords(config *Config, edges *edgeRecords) error {
+	for _, cluster := range config.Kubernetes {
+		name := cluster.displayName()
+		fmt.Printf("Fetching routes from Kubernetes %s\n", name)
+		client, err := kubernetes.NewClient(cluster.Kubeconfig, cluster.Context, cluster.timeout())
+		if err != nil {
+			return err
+		}
+		routes, err := client.GetRoutes()
+		if err != nil {
+			return err
+		}
+
+		clusterDomains := cluster.Domains
+		if len(clusterDomains) == 0 {
+			clusterDomains = config.publicDomains()
+		}
+
+		cnames := 0
+		hosts := 0
+		for _, route := range routes {
+			if cluster.Ingress == "" && len(route.Addresses) == 0 {
+				if len(route.Hosts) != 0 {
+					fmt.Printf("Skipping %s %s/%s (no load balancer address)\n", route.Kind, route.Namespace, route.Name)
+				}
+				continue
+			}
+			for _, host := range route.Hosts {
+				if cluster.Ingress != "" {
+					if edges.add(name, host, clusterDomains, config.WebEdge, cluster.Ingress) {
+						cnames++
+					}
+					continue
+				}
+				if edges.addAddresses(name, host, clusterDomains, route.Addresses) {
+					hosts++
+				}
+			}
+		}
+		fmt.Printf("%d Ingress and HTTPRoute Objects Found\n", len(routes))
+		fmt.Printf("%d CNAME Hosts From Kubernetes\n", cnames)
+		fmt.Printf("%d Host Records From Kubernetes\n", hosts)
+	}
+	return nil
+}
@@ -5,40 +5,10 @@ import (
 	"fmt"
 	"os"
 	"slices"
-	"strings"
-	"unipidns/internal/nginxproxymanager"
-	"unipidns/internal/pihole"
+	"unipidns/internal/records"
 	"unipidns/internal/unificontroller"
 )
 
-type Config struct {
-	Unifi             *Unifi             `json:"unifi"`
-	PiHole            []PiHole           `json:"pihole"`
-	NginxProxyManager *NginxProxyManager `json:"nginxProxyManager"`
-	Domain            string             `json:"domain"`
-	WebEdge           string             `json:"webEdge"`
-	Local             string             `json:"local"`
-}
-
-type Unifi struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Url      string `json:"url"`
-	Site     string `json:"site"`
-}
-
-type PiHole struct {
-	Url      string `json:"url"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
-}
-
-type NginxProxyManager struct {
-	Url      string `json:"url"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-}
-
 func check(e error) {
 	if e != nil {
 		panic(e)
@@ -56,104 +26,76 @@ func main() {
 	check(err)
 	fmt.Println()
 	fmt.Printf("Unifi Controller Url: %s\n", config.Unifi.Url)
-	fmt.Printf("Nginx Proxy Manager Url: %s\n", config.NginxProxyManager.Url)
+	for _, npm := range config.npmInstances() {
+		fmt.Printf("Nginx Proxy Manager %s Url: %s\n", npm.displayName(), npm.Url)
+	}
+	for _, traefik := range config.Traefik {
+		fmt.Printf("Traefik %s Url: %s\n", traefik.displayName(), traefik.Url)
+	}
+	for _, caddy := range config.Caddy {
+		fmt.Printf("Caddy %s Url: %s\n", caddy.displayName(), caddy.Url)
+	}
+	for _, docker := range config.Docker {
+		fmt.Printf("Docker %s Url: %s\n", docker.displayName(), docker.Url)
+	}
+	for _, kubernetes := range config.Kubernetes {
+		fmt.Printf("Kubernetes %s Context: %s\n", kubernetes.displayName(), kubernetes.Context)
+	}
 	for _, pihole := range config.PiHole {
 		fmt.Printf("PiHole %s Url: %s\n", pihole.Name, pihole.Url)
 	}
+	for _, target := range config.DnsTargets {
+		fmt.Printf("%s %s %s\n", target.Type, target.displayName(), target.endpoint())
+	}
 	fmt.Println()
-	fixedIps, err := unificontroller.GetFixedIpClients(config.Unifi.Username, config.Unifi.Password, config.Unifi.Url, config.Unifi.Site)
-
-	for idx, client := range fixedIps {
-		fixedIps[idx].Name = fmt.Sprintf("%s.%s", strings.ToLower(client.Name), config.Local)
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAudit(config))
 	}
-
-	var fixedIpClients []string
-	for _, client := range fixedIps {
-		fixedIpClients = append(fixedIpClients, fmt.Sprintf("%s %s", client.Ip, client.Name))
+	dryRun := slices.Contains(os.Args[1:], "--dry-run")
+	if dryRun {
+		fmt.Println("Dry run, changes are listed but not made")
+		fmt.Println()
 	}
-	fmt.Printf("%d Fixed IP Clients Found\n", len(fixedIpClients))
+	runSync(config, dryRun)
+}
 
+func runSync(config *Config, dryRun bool) {
+	fixedIps, unifiRecords, err := unificontroller.GetFixedIpClients(config.Unifi)
 	check(err)
+	fixedIpClients, unifiCnames := buildHostRecords(config, fixedIps, unifiRecords)
+	fmt.Printf("%d Host Records Built\n", len(fixedIpClients))
 
-	hosts, err := nginxproxymanager.GetProxyHosts(config.NginxProxyManager.Username, config.NginxProxyManager.Password, config.NginxProxyManager.Url)
+	edges, err := buildEdgeRecords(config, fixedIpClients)
+	check(err)
+	dockerHosts, dockerCnames, err := buildDockerRecords(config, fixedIpClients, edges)
 	check(err)
-	var cnameHosts []string
-	for _, host := range hosts {
-		if strings.HasSuffix(host, config.Domain) {
-			cnameHosts = append(cnameHosts, host)
-		}
-	}
-	for idx, cnameHost := range cnameHosts {
-		cnameHosts[idx] = fmt.Sprintf("%s,%s.%s", cnameHost, config.WebEdge, config.Local)
-	}
-
-	fmt.Printf("%d CNAME Hosts Found\n", len(cnameHosts))
-
-	fmt.Println()
-	for _, piHoleConfig := range config.PiHole {
-		fmt.Println("Processing DNS on PiHole: " + piHoleConfig.Name)
-		pihole.ClearAuth()
-		aRecords, cnames, err := pihole.GetLocalDns(piHoleConfig.Url, piHoleConfig.Password)
-		check(err)
-		fmt.Printf("	%d A Records Found\n", len(aRecords))
-		fmt.Printf("	%d CNAME Records Found\n", len(cnames))
-
-		check(err)
-		var hostsToAdd []string
-		var cnamesToAdd []string
-		var hostsToRemove []string
-		var cnamesToRemove []string
-
-		// Add Fixed IP Clients
-		for _, clientIp := range fixedIpClients {
-			if !slices.Contains(aRecords, clientIp) {
-				hostsToAdd = append(hostsToAdd, clientIp)
-			}
 
-		}
-		// Remove Fixed IP Clients
-		for _, aRecord := range aRecords {
-			if !slices.Contains(fixedIpClients, aRecord) {
-				hostsToRemove = append(hostsToRemove, aRecord)
+	desired := &desiredState{domains: config.allDomains(), local: config.Local, dryRun: dryRun}
+	for _, hostLines := range [][]string{fixedIpClients, edges.hosts, dockerHosts} {
+		for _, line := range hostLines {
+			if record, ok := records.ParseHost(line); ok {
+				desired.records.Add(record)
 			}
 		}
-		// Add CNAME Hosts
-		for _, cnameHost := range cnameHosts {
-			if !slices.Contains(cnames, cnameHost) {
-				cnamesToAdd = append(cnamesToAdd, cnameHost)
-			}
-		}
-		// Remove CNAME Hosts
-		for _, cname := range cnames {
-			if !slices.Contains(cnameHosts, cname) {
-				cnamesToRemove = append(cnamesToRemove, cname)
+	}
+	for _, cnameLines := range [][]string{edges.cnameHosts, unifiCnames, dockerCnames} {
+		for _, line := range cnameLines {
+			if record, ok := records.ParseCname(line); ok {
+				desired.records.Add(record)
 			}
 		}
+	}
+	desired.records.Add(edges.wildcards...)
 
-		fmt.Printf("	%d A Records to Add\n", len(hostsToAdd))
-		fmt.Printf("	%d A Records to Remove\n", len(hostsToRemove))
-		fmt.Printf("	%d CNAME Records to Add\n", len(cnamesToAdd))
-		fmt.Printf("	%d CNAME Records to Remove\n", len(cnamesToRemove))
-
-		for _, host := range hostsToAdd {
-			err = pihole.AddLocalDns(piHoleConfig.Url, piHoleConfig.Password, strings.Split(host, " ")[1], strings.Split(host, " ")[0])
-			check(err)
-		}
-
-		for _, host := range hostsToRemove {
-			err = pihole.RemoveLocalDns(piHoleConfig.Url, piHoleConfig.Password, strings.Split(host, " ")[1], strings.Split(host, " ")[0])
-			check(err)
-		}
-
-		for _, cname := range cnamesToAdd {
-			err = pihole.AddCname(piHoleConfig.Url, piHoleConfig.Password, strings.Split(cname, ",")[0], strings.Split(cname, ",")[1])
-			check(err)
-		}
-
-		for _, cname := range cnamesToRemove {
-			err = pihole.RemoveCname(piHoleConfig.Url, piHoleConfig.Password, strings.Split(cname, ",")[0], strings.Split(cname, ",")[1])
-			check(err)
-		}
+	fmt.Printf("%d CNAME Hosts Found\n", len(desired.records.Cnames()))
+	fmt.Printf("%d Wildcard Records Built\n", len(desired.records.Wildcards()))
 
+	targets, err := config.dnsTargets()
+	check(err)
+	for _, target := range targets {
+		fmt.Println()
+		fmt.Println("Processing DNS on " + target.displayName())
+		err = target.sync(desired)
+		check(err)
 	}
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unipidns/internal/nginxproxymanager"
+)
+
+// addNpmRecords adds the hosts from every Nginx Proxy Manager instance to edges
+func addNpmRecords(config *Config, edges *edgeRecords) error {
+	for _, instance := range config.npmInstances() {
+		name := instance.displayName()
+		fmt.Printf("Fetching hosts from Nginx Proxy Manager %s\n", name)
+		// streams forward a port rather than a domain, there is nothing to publish for them so only the
+		// audit reads them
+		hostTypes := slices.DeleteFunc(slices.Clone(instance.HostTypes), func(hostType string) bool {
+			return hostType == nginxproxymanager.StreamHost
+		})
+		if len(hostTypes) != len(instance.HostTypes) {
+			fmt.Println("Streams have no domain names, so they are left out of the records")
+			if len(hostTypes) == 0 {
+				continue
+			}
+		}
+		npm := nginxproxymanager.NewClient(instance.Url, instance.Username, instance.Password, &http.Client{Timeout: instance.timeout()})
+		hosts, err := npm.GetProxyHosts(hostTypes)
+		if err != nil {
+			return err
+		}
+
+		internalLists, err := internalAccessLists(npm, instance.SplitHorizon)
+		if err != nil {
+			return err
+		}
+
+		instanceDomains := instance.Domains
+		if len(instanceDomains) == 0 {
+			instanceDomains = config.publicDomains()
+		}
+		defaultEdge := config.WebEdge
+		if instance.WebEdge != "" {
+			defaultEdge = instance.WebEdge
+		}
+
+		cnameTypes := map[string]int{}
+		skipped := 0
+		for _, host := range hosts {
+			if reason := host.SkipReason(instance.SkipOffline); reason != "" {
+				if len(host.DomainNames) != 0 {
+					fmt.Printf("Skipping %s host %s (%s)\n", host.Type, strings.Join(host.DomainNames, ", "), reason)
+					skipped++
+				}
+				continue
+			}
+			horizonEdge := ""
+			if instance.SplitHorizon != nil {
+				internal := host.AccessListID != 0 && (!instance.SplitHorizon.InspectAccessLists || internalLists[host.AccessListID])
+				horizonEdge = instance.SplitHorizon.Public
+				if internal {
+					horizonEdge = instance.SplitHorizon.Internal
+				}
+				if horizonEdge == skipHosts {
+					if len(host.DomainNames) != 0 {
+						fmt.Printf("Skipping %s host %s (%s)\n", host.Type, strings.Join(host.DomainNames, ", "), horizonName(internal))
+						skipped++
+					}
+					continue
+				}
+			}
+			for _, domain := range host.DomainNames {
+				if edges.add(name, strings.ToLower(domain), instanceDomains, defaultEdge, horizonEdge) {
+					cnameTypes[host.Type]++
+				}
+			}
+		}
+		if skipped != 0 {
+			fmt.Printf("%d Nginx Proxy Manager Hosts Skipped\n", skipped)
+		}
+		for _, hostType := range slices.Sorted(maps.Keys(cnameTypes)) {
+			fmt.Printf("%d CNAME Hosts From %s Hosts\n", cnameTypes[hostType], hostType)
+		}
+	}
+	return nil
+}
+
+// internalAccessLists returns the IDs of the access lists that only let in private addresses, when the
+// split horizon config asks for them to be inspected
+func internalAccessLists(npm *nginxproxymanager.Client, splitHorizon *SplitHorizon) (map[int]bool, error) {
+	internal := map[int]bool{}
+	if splitHorizon == nil || !splitHorizon.InspectAccessLists {
+		return internal, nil
+	}
+	accessLists, err := npm.GetAccessLists()
+	if err != nil {
+		return nil, err
+	}
+	for _, accessList := range accessLists {
+		if accessList.InternalOnly() {
+			internal[accessList.ID] = true
+		} else {
+			fmt.Printf("Access list %s allows public clients, its hosts are treated as public\n", accessList.Name)
+		}
+	}
+	return internal, nil
+}
+
+func horizonName(internal bool) string {
+	if internal {
+		return "internal"
+	}
+	return "public"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+// npmServer answers the token and proxy host requests of an Nginx Proxy Manager with proxyHosts, and
+// the access list requests with accessLists when it is set
+func npmServer(t *testing.T, proxyHosts string, accessLists string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		switch {
+		case r.URL.Path == "/api/tokens":
+			w.Write([]byte(`{"token": "test"}`))
+		case r.URL.Path == "/api/nginx/proxy-hosts":
+			w.Write([]byte(proxyHosts))
+		case r.URL.Path == "/api/nginx/access-lists" && accessLists != "":
+			w.Write([]byte(accessLists))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNpmConflicts(t *testing.T) {
+	first := npmServer(t, `[
+		{"id": 1, "domain_names": ["files.awesome.com", "Grafana.Apps.awesome.com"], "enabled": true},
+		{"id": 2, "domain_names": ["*.apps.awesome.com"], "enabled": true},
+		{"id": 3, "domain_names": ["www.other.net"], "enabled": true}
+	]`, "")
+	second := npmServer(t, `[
+		{"id": 1, "domain_names": ["files.awesome.com"], "enabled": true},
+		{"id": 2, "domain_names": ["grafana.apps.awesome.com"], "enabled": true},
+		{"id": 3, "domain_names": ["music.awesome.com"], "enabled": true}
+	]`, "")
+	config := &Config{
+		Local:   "lan",
+		WebEdge: "edge",
+		Domains: []domains.Domain{{Name: "awesome.com"}, {Name: "apps.awesome.com", WebEdge: "apps-edge"}},
+		NginxProxyManagers: []NginxProxyManager{
+			{source: source{Name: "first", Url: first.URL}, Username: "user", Password: "pass"},
+			{source: source{Name: "second", Url: second.URL}, Username: "user", Password: "pass", WebEdge: "edge-2"},
+		},
+	}
+	hostRecords := []string{"192.168.1.2 edge.lan", "192.168.1.3 apps-edge.lan", "192.168.1.4 edge-2.lan"}
+
+	edges, err := buildEdgeRecords(config, hostRecords)
+	if err != nil {
+		t.Fatalf("Error building records: %s", err)
+	}
+	expected := []string{
+		"files.awesome.com,edge.lan",
+		// apps.awesome.com is the longest match, so its web edge wins over the default
+		"grafana.apps.awesome.com,apps-edge.lan",
+		"music.awesome.com,edge-2.lan",
+	}
+	if !slices.Equal(edges.cnameHosts, expected) {
+		t.Errorf("Expected %v, got %v", expected, edges.cnameHosts)
+	}
+	if !slices.Equal(edges.wildcards, []records.Record{records.Address("*.apps.awesome.com", "192.168.1.3")}) {
+		t.Errorf("Expected the wildcard to point at apps-edge, got %v", edges.wildcards)
+	}
+	// the second instance wants files.awesome.com on its own web edge, grafana ends up on the same one so
+	// it is not a conflict
+	if edges.conflicts != 1 {
+		t.Errorf("Expected 1 conflict, got %d", edges.conflicts)
+	}
+	if claim := edges.claims["files.awesome.com"]; claim.source != "first" {
+		t.Errorf("Expected the first instance to keep files.awesome.com, got %s", claim.source)
+	}
+}
+
+func TestNpmStreamsNotFetched(t *testing.T) {
+	// npmServer fails the test if the streams are requested
+	server := npmServer(t, `[{"id": 1, "domain_names": ["files.awesome.com"], "enabled": true}]`, "")
+	config := &Config{
+		Local:   "lan",
+		WebEdge: "edge",
+		Domain:  "awesome.com",
+		NginxProxyManagers: []NginxProxyManager{
+			{source: source{Name: "proxies", Url: server.URL}, HostTypes: []string{"proxy", "stream"}},
+			{source: source{Name: "streams", Url: server.URL}, HostTypes: []string{"stream"}},
+		},
+	}
+	edges, err := buildEdgeRecords(config, nil)
+	if err != nil {
+		t.Fatalf("Error building records: %s", err)
+	}
+	if !slices.Equal(edges.cnameHosts, []string{"files.awesome.com,edge.lan"}) {
+		t.Errorf("Expected only the proxy host, got %v", edges.cnameHosts)
+	}
+}
+
+func TestNpmSplitHorizon(t *testing.T) {
+	server := npmServer(t, `[
+		{"id": 1, "domain_names": ["internal.awesome.com"], "enabled": true, "access_list_id": 1},
+		{"id": 2, "domain_names": ["public.awesome.com"], "enabled": true},
+		{"id": 3, "domain_names": ["open.awesome.com"], "enabled": true, "access_list_id": 2}
+	]`, `[
+		{"id": 1, "name": "lan only", "clients": [{"address": "192.168.0.0/16", "directive": "allow"}, {"address": "all", "directive": "deny"}]},
+		{"id": 2, "name": "anyone", "clients": [{"address": "0.0.0.0/0", "directive": "allow"}]}
+	]`)
+	tests := []struct {
+		name         string
+		splitHorizon SplitHorizon
+		expected     []string
+	}{
+		{"defaults", SplitHorizon{}, []string{"internal.awesome.com,edge.lan", "public.awesome.com,edge.lan", "open.awesome.com,edge.lan"}},
+		// without inspecting the lists any host with an access list is internal
+		{"skip public", SplitHorizon{Public: skipHosts}, []string{"internal.awesome.com,edge.lan", "open.awesome.com,edge.lan"}},
+		{"skip internal", SplitHorizon{Internal: skipHosts}, []string{"public.awesome.com,edge.lan"}},
+		{"internal edge", SplitHorizon{Internal: "internal-edge", Public: "public-edge"}, []string{"internal.awesome.com,internal-edge.lan", "public.awesome.com,public-edge.lan", "open.awesome.com,internal-edge.lan"}},
+		// the second list lets in public addresses, so its host is public
+		{"inspected", SplitHorizon{Internal: "internal-edge", Public: "public-edge", InspectAccessLists: true}, []string{"internal.awesome.com,internal-edge.lan", "public.awesome.com,public-edge.lan", "open.awesome.com,public-edge.lan"}},
+		{"inspected skip public", SplitHorizon{Public: skipHosts, InspectAccessLists: true}, []string{"internal.awesome.com,edge.lan"}},
+	}
+	for _, test := range tests {
+		config := &Config{
+			Local:   "lan",
+			WebEdge: "edge",
+			Domain:  "awesome.com",
+			NginxProxyManagers: []NginxProxyManager{
+				{source: source{Name: "npm", Url: server.URL}, SplitHorizon: &test.splitHorizon},
+			},
+		}
+		edges := newEdgeRecords(config, nil)
+		err := addNpmRecords(config, edges)
+		if err != nil {
+			t.Fatalf("Error adding records for %s: %s", test.name, err)
+		}
+		if !slices.Equal(edges.cnameHosts, test.expected) {
+			t.Errorf("Expected %v for %s, got %v", test.expected, test.name, edges.cnameHosts)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unipidns/internal/pihole"
+	"unipidns/internal/records"
+)
+
+func (p PiHole) displayName() string {
+	return "PiHole: " + p.Name
+}
+
+// sync reconciles the PiHole local DNS. Every host and CNAME record on the PiHole belongs to this
+// tool, while of the dnsmasq lines only the wildcard address lines pointing at a web edge do.
+func (p PiHole) sync(desired *desiredState) error {
+	var wantedHosts, wantedCnames, wantedWildcards []records.Record
+	for _, record := range desired.records.Records {
+		switch {
+		case record.Wildcard() && record.Type != records.CNAME:
+			wantedWildcards = append(wantedWildcards, record)
+		case record.Wildcard():
+		case record.Type == records.CNAME:
+			wantedCnames = append(wantedCnames, record)
+		default:
+			wantedHosts = append(wantedHosts, record)
+		}
+	}
+
+	pihole.ClearAuth()
+	pihole.SetTimeout(p.timeout())
+	aRecords, cnames, err := pihole.GetLocalDns(p.Url, p.Password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("	%d A Records Found\n", len(aRecords))
+	fmt.Printf("	%d CNAME Records Found\n", len(cnames))
+	// the lines as the PiHole has them, which are what it needs to remove them
+	lines := map[records.Record]string{}
+	var currentHosts, currentCnames []records.Record
+	for _, line := range aRecords {
+		if record, ok := records.ParseHost(line); ok {
+			lines[record] = line
+			currentHosts = append(currentHosts, record)
+		}
+	}
+	for _, line := range cnames {
+		if record, ok := records.ParseCname(line); ok {
+			lines[record] = line
+			currentCnames = append(currentCnames, record)
+		}
+	}
+	hostsToAdd, hostsToRemove := records.Diff(currentHosts, wantedHosts, nil)
+	cnamesToAdd, cnamesToRemove := records.Diff(currentCnames, wantedCnames, nil)
+
+	// Wildcards are dnsmasq lines, only the lines this tool could have written are touched
+	existingLines, err := pihole.GetDnsmasqLines(p.Url, p.Password)
+	if err != nil {
+		return err
+	}
+	var currentWildcards []records.Record
+	for _, line := range existingLines {
+		if !ownsDnsmasqLine(line, desired.domains) {
+			continue
+		}
+		if record, ok := parseDnsmasqLine(line); ok {
+			lines[record] = line
+			currentWildcards = append(currentWildcards, record)
+		}
+	}
+	wildcardsToAdd, wildcardsToRemove := records.Diff(currentWildcards, wantedWildcards, nil)
+
+	fmt.Printf("	%d A Records to Add\n", len(hostsToAdd))
+	fmt.Printf("	%d A Records to Remove\n", len(hostsToRemove))
+	fmt.Printf("	%d CNAME Records to Add\n", len(cnamesToAdd))
+	fmt.Printf("	%d CNAME Records to Remove\n", len(cnamesToRemove))
+	fmt.Printf("	%d Wildcard Lines to Add\n", len(wildcardsToAdd))
+	fmt.Printf("	%d Wildcard Lines to Remove\n", len(wildcardsToRemove))
+
+	if desired.dryRun {
+		printChanges(slices.Concat(hostsToAdd, cnamesToAdd, wildcardsToAdd), slices.Concat(hostsToRemove, cnamesToRemove, wildcardsToRemove))
+		return nil
+	}
+
+	for _, record := range hostsToAdd {
+		err = pihole.AddLocalDns(p.Url, p.Password, record.Name, record.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range hostsToRemove {
+		fields := strings.Fields(lines[record])
+		err = pihole.RemoveLocalDns(p.Url, p.Password, fields[1], fields[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range cnamesToAdd {
+		err = pihole.AddCname(p.Url, p.Password, record.Name, record.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range cnamesToRemove {
+		fields := strings.Split(lines[record], ",")
+		err = pihole.RemoveCname(p.Url, p.Password, fields[0], fields[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range wildcardsToAdd {
+		err = pihole.AddDnsmasqLine(p.Url, p.Password, dnsmasqLine(record))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range wildcardsToRemove {
+		err = pihole.RemoveDnsmasqLine(p.Url, p.Password, lines[record])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+// piHoleServer is a fake PiHole holding dnsmasq lines, which records the changes made to it
+type piHoleServer struct {
+	t            *testing.T
+	dnsmasqLines []string
+	changes      []string
+}
+
+func (s *piHoleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const linesPath = "/api/config/misc/dnsmasq_lines/"
+	var response interface{}
+	switch {
+	case r.URL.Path == "/api/auth":
+		response = map[string]interface{}{"session": map[string]interface{}{"valid": true, "sid": "sid", "csrf": "csrf", "validity": 300, "message": "password correct"}}
+	case r.URL.Path == "/api/config":
+		response = map[string]interface{}{"config": map[string]interface{}{"misc": map[string]interface{}{"dnsmasq_lines": s.dnsmasqLines}}}
+	case strings.HasPrefix(r.URL.EscapedPath(), linesPath):
+		line, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), linesPath))
+		if err != nil {
+			s.t.Errorf("Error unescaping %s: %s", r.URL.EscapedPath(), err)
+		}
+		s.changes = append(s.changes, r.Method+" "+line)
+		if r.Method == "PUT" {
+			w.WriteHeader(201)
+		} else {
+			w.WriteHeader(204)
+		}
+		return
+	default:
+		s.t.Errorf("Unexpected path %s", r.URL.Path)
+	}
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		s.t.Errorf("Error marshalling response: %s", err)
+	}
+	w.Write(responseJson)
+}
+
+func TestPiHoleEdgeAddressChange(t *testing.T) {
+	fake := &piHoleServer{t: t, dnsmasqLines: []string{
+		// written when the web edge was still at .2
+		"address=/apps.awesome.com/192.168.1.2",
+		"address=/ads.awesome.com/0.0.0.0",
+		"address=/apps.other.net/192.168.1.2",
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	desired := &desiredState{local: "lan", domains: []domains.Domain{{Name: "awesome.com"}}}
+	desired.records.Add(records.Address("*.apps.awesome.com", "192.168.1.3"))
+	piHole := PiHole{source: source{Name: "test", Url: server.URL}}
+	err := piHole.sync(desired)
+	if err != nil {
+		t.Fatalf("Error syncing: %s", err)
+	}
+	expected := []string{
+		"PUT address=/apps.awesome.com/192.168.1.3",
+		"DELETE address=/apps.awesome.com/192.168.1.2",
+	}
+	if !slices.Equal(fake.changes, expected) {
+		t.Errorf("Expected %v, got %v", expected, fake.changes)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unipidns/internal/records"
+	"unipidns/internal/rfc2136"
+)
+
+// defaultTtl is used for records written to authoritative servers when no ttl is configured
+const defaultTtl = 3600
+
+// rfc2136Marker is the value of the TXT record that marks a name outside the local suffix as published
+// by this tool, as zones for public domains usually hold records made by hand as well
+const rfc2136Marker = "unipidns"
+
+// markerName is where the marker for name goes. A TXT record can't sit next to a CNAME, so the marker
+// gets a name of its own.
+func markerName(name string) string {
+	return "_unipidns." + strings.Replace(name, "*", "_wildcard", 1)
+}
+
+// rfc2136Target publishes the records to an authoritative server with dynamic updates
+type rfc2136Target struct {
+	name         string
+	zones        []string
+	reverseZones []string
+	ttl          uint32
+	client       *rfc2136.Client
+}
+
+func newRfc2136Target(target DnsTarget, local string) *rfc2136Target {
+	zones := target.Zones
+	if len(zones) == 0 {
+		zones = []string{local}
+	}
+	return &rfc2136Target{
+		name:         target.Name,
+		zones:        zones,
+		reverseZones: target.ReverseZones,
+		ttl:          target.ttl(),
+		client:       rfc2136.NewClient(target.Server, target.TsigKey, target.TsigSecret, target.TsigAlgorithm, target.timeout()),
+	}
+}
+
+func (r *rfc2136Target) displayName() string {
+	return "RFC 2136: " + r.name
+}
+
+// sync reads each zone with a zone transfer and sends the differences as one update per zone.
+func (r *rfc2136Target) sync(desired *desiredState) error {
+	allZones := append(append([]string{}, r.zones...), r.reverseZones...)
+	wanted := r.wantedRecords(desired)
+	for _, zone := range allZones {
+		zoneRecords, err := r.client.Transfer(zone)
+		if err != nil {
+			return err
+		}
+		current, owned := zoneOwnership(zone, recordZone(zone, r.reverseZones) == zone, allZones, desired.local, zoneRecords)
+		add, remove := records.Diff(current, wanted[zone], owned)
+		fmt.Printf("	Zone %s: %d Records Found, %d to Add, %d to Remove\n", zone, len(current), len(add), len(remove))
+		if desired.dryRun {
+			printChanges(add, remove)
+			continue
+		}
+		if len(add) == 0 && len(remove) == 0 {
+			continue
+		}
+		err = r.client.Update(zone, r.rfc2136Records(add), r.rfc2136Records(remove))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wantedRecords sorts the desired records into the zones, with a marker for each name outside the local
+// suffix. Records that are not in any of the zones are skipped.
+func (r *rfc2136Target) wantedRecords(desired *desiredState) map[string][]records.Record {
+	wanted := map[string][]records.Record{}
+	skipped := 0
+	for _, record := range desired.records.Records {
+		zone := recordZone(record.Name, r.zones)
+		if zone == "" {
+			skipped++
+			continue
+		}
+		wanted[zone] = append(wanted[zone], record)
+		marker := records.Record{Type: records.TXT, Name: markerName(record.Name), Value: rfc2136Marker}
+		if !records.InZone(record.Name, desired.local) && !slices.Contains(wanted[zone], marker) {
+			wanted[zone] = append(wanted[zone], marker)
+		}
+	}
+	for _, ptr := range desired.records.Ptrs() {
+		if zone := recordZone(ptr.Name, r.reverseZones); zone != "" {
+			wanted[zone] = append(wanted[zone], ptr)
+		}
+	}
+	if skipped != 0 {
+		fmt.Printf("	%d Records Skipped, they are not in any of the zones\n", skipped)
+	}
+	return wanted
+}
+
+func (r *rfc2136Target) rfc2136Records(list []records.Record) []rfc2136.Record {
+	var converted []rfc2136.Record
+	for _, record := range list {
+		converted = append(converted, rfc2136.Record{Name: record.Name, Type: record.Type, Value: record.Value, TTL: r.ttl})
+	}
+	return converted
+}
+
+// zoneOwnership returns the records of a transferred zone that are compared with the desired ones,
+// and which of them this tool owns. In a forward zone that is every A, AAAA and CNAME record inside the
+// local suffix, and outside it only names with a marker. In a reverse zone it is every PTR record. The
+// apex and the addresses of the nameservers named by NS records are never owned, as the server refuses
+// to load a zone whose in-zone nameserver has no address.
+func zoneOwnership(zone string, reverse bool, allZones []string, local string, zoneRecords []rfc2136.Record) ([]records.Record, func(records.Record) bool) {
+	nameservers := map[string]bool{}
+	markers := map[string]bool{}
+	var current []records.Record
+	for _, zoneRecord := range zoneRecords {
+		switch {
+		case zoneRecord.Type == "NS":
+			nameservers[zoneRecord.Value] = true
+			continue
+		case zoneRecord.Type == records.TXT && zoneRecord.Value != rfc2136Marker:
+			continue
+		case zoneRecord.Type == records.TXT:
+			markers[zoneRecord.Name] = true
+		}
+		// glue for a more specific zone in the list is left to that zone
+		if recordZone(zoneRecord.Name, allZones) != zone {
+			continue
+		}
+		current = append(current, records.Record{Type: zoneRecord.Type, Name: zoneRecord.Name, Value: zoneRecord.Value})
+	}
+	return current, func(record records.Record) bool {
+		switch {
+		case record.Name == zone || nameservers[record.Name]:
+			return false
+		case reverse || record.Type == records.PTR:
+			return reverse && record.Type == records.PTR
+		case record.Type == records.TXT:
+			return true
+		case local != "" && records.InZone(record.Name, local):
+			return true
+		}
+		return markers[markerName(record.Name)]
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"unipidns/internal/records"
+	"unipidns/internal/rfc2136"
+)
+
+func TestZoneOwnership(t *testing.T) {
+	zoneRecords := []rfc2136.Record{
+		{Name: "lan", Type: "NS", Value: "ns.lan"},
+		{Name: "lan", Type: "A", Value: "192.168.1.2"},
+		{Name: "ns.lan", Type: "A", Value: "192.168.1.2"},
+		{Name: "nas.lan", Type: "A", Value: "192.168.1.10"},
+		{Name: "old.lan", Type: "A", Value: "192.168.1.11"},
+		{Name: "iot.lan", Type: "NS", Value: "ns.iot.lan"},
+		{Name: "ns.iot.lan", Type: "A", Value: "192.168.5.2"},
+		{Name: "printer.iot.lan", Type: "A", Value: "192.168.5.20"},
+	}
+	allZones := []string{"lan", "iot.lan"}
+	current, owned := zoneOwnership("lan", false, allZones, "lan", zoneRecords)
+	if len(current) != 4 {
+		t.Errorf("Expected the 4 address records of lan, got %v", current)
+	}
+	desired := []records.Record{records.Address("nas.lan", "192.168.1.10")}
+	_, remove := records.Diff(current, desired, owned)
+	if !slices.Equal(remove, []records.Record{records.Address("old.lan", "192.168.1.11")}) {
+		t.Errorf("Expected only old.lan to be removed, got %v", remove)
+	}
+
+	tests := []struct {
+		record records.Record
+		owned  bool
+	}{
+		{records.Address("ns.lan", "192.168.1.2"), false},
+		{records.Address("ns.iot.lan", "192.168.5.2"), false},
+		{records.Address("lan", "192.168.1.2"), false},
+		{records.Cname("files.lan", "nas.lan"), true},
+		{records.Record{Type: records.PTR, Name: "10.1.168.192.in-addr.arpa", Value: "nas.lan"}, false},
+	}
+	for _, test := range tests {
+		if owned(test.record) != test.owned {
+			t.Errorf("Expected %s owned to be %t", test.record, test.owned)
+		}
+	}
+
+	_, owned = zoneOwnership("1.168.192.in-addr.arpa", true, []string{"1.168.192.in-addr.arpa"}, "lan", nil)
+	if !owned(records.Record{Type: records.PTR, Name: "10.1.168.192.in-addr.arpa", Value: "nas.lan"}) {
+		t.Errorf("Expected PTR records in a reverse zone to be owned")
+	}
+}
+
+func TestZoneOwnershipDomainZone(t *testing.T) {
+	zoneRecords := []rfc2136.Record{
+		{Name: "awesome.com", Type: "NS", Value: "ns1.awesome.com"},
+		{Name: "ns1.awesome.com", Type: "A", Value: "203.0.113.2"},
+		// made by hand
+		{Name: "www.awesome.com", Type: "A", Value: "203.0.113.10"},
+		{Name: "mail.awesome.com", Type: "A", Value: "203.0.113.11"},
+		{Name: "awesome.com", Type: "TXT", Value: "v=spf1 mx -all"},
+		// published by this tool
+		{Name: "files.awesome.com", Type: "CNAME", Value: "edge.lan"},
+		{Name: "_unipidns.files.awesome.com", Type: "TXT", Value: "unipidns"},
+		{Name: "old.awesome.com", Type: "CNAME", Value: "edge.lan"},
+		{Name: "_unipidns.old.awesome.com", Type: "TXT", Value: "unipidns"},
+	}
+	target := &rfc2136Target{zones: []string{"lan", "awesome.com"}}
+	desired := &desiredState{local: "lan"}
+	desired.records.Add(records.Address("nas.lan", "192.168.1.10"), records.Cname("files.awesome.com", "edge.lan"), records.Address("*.apps.awesome.com", "192.168.1.2"))
+	wanted := target.wantedRecords(desired)
+
+	expected := []records.Record{
+		records.Cname("files.awesome.com", "edge.lan"),
+		{Type: records.TXT, Name: "_unipidns.files.awesome.com", Value: "unipidns"},
+		records.Address("*.apps.awesome.com", "192.168.1.2"),
+		{Type: records.TXT, Name: "_unipidns._wildcard.apps.awesome.com", Value: "unipidns"},
+	}
+	if !slices.Equal(wanted["awesome.com"], expected) {
+		t.Errorf("Expected %v, got %v", expected, wanted["awesome.com"])
+	}
+	// names in the local suffix don't need a marker
+	if !slices.Equal(wanted["lan"], []records.Record{records.Address("nas.lan", "192.168.1.10")}) {
+		t.Errorf("Expected nas.lan without a marker, got %v", wanted["lan"])
+	}
+
+	current, owned := zoneOwnership("awesome.com", false, []string{"lan", "awesome.com"}, "lan", zoneRecords)
+	add, remove := records.Diff(current, wanted["awesome.com"], owned)
+	if !slices.Equal(add, expected[2:]) {
+		t.Errorf("Expected the wildcard and its marker to be added, got %v", add)
+	}
+	expectedRemove := []records.Record{
+		records.Cname("old.awesome.com", "edge.lan"),
+		{Type: records.TXT, Name: "_unipidns.old.awesome.com", Value: "unipidns"},
+	}
+	if !slices.Equal(remove, expectedRemove) {
+		t.Errorf("Expected only old.awesome.com and its marker to be removed, got %v", remove)
+	}
+}
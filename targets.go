@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+// desiredState is what every DNS target is synced to
+type desiredState struct {
+	records records.Set
+	// domains are the public domains records are published for, used to decide what a target owns
+	domains []domains.Domain
+	local   string
+	// dryRun lists the changes without making them
+	dryRun bool
+}
+
+// owns reports whether name is the local suffix, one of the configured domains or below them. Targets
+// only remove records they own, so anything else on the server is left alone.
+func (d *desiredState) owns(name string) bool {
+	name = strings.TrimPrefix(strings.ToLower(name), "*.")
+	if d.local != "" && records.InZone(name, d.local) {
+		return true
+	}
+	_, ok := domains.Match(d.domains, name)
+	return ok
+}
+
+// dnsTarget is a DNS server the desired records are published to
+type dnsTarget interface {
+	displayName() string
+	// sync adds the missing records and removes the stale ones the target owns
+	sync(desired *desiredState) error
+}
+
+// dnsTargets returns the PiHoles followed by the entries in dnsTargets
+func (c *Config) dnsTargets() ([]dnsTarget, error) {
+	var targets []dnsTarget
+	for _, piHole := range c.PiHole {
+		targets = append(targets, piHole)
+	}
+	for _, target := range c.DnsTargets {
+		err := target.validate()
+		if err != nil {
+			return nil, err
+		}
+		switch target.Type {
+		case "pihole":
+			targets = append(targets, PiHole{source: target.source, Password: target.Password})
+		case "adguard":
+			targets = append(targets, newAdGuardTarget(target))
+		case "technitium":
+			targets = append(targets, newTechnitiumTarget(target))
+		case "rfc2136":
+			targets = append(targets, newRfc2136Target(target, c.Local))
+		case "zonefile":
+			zoneFiles, err := newZoneFileTarget(target, c.Local)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, zoneFiles)
+		case "hosts", "dnsmasq":
+			newFileTarget := newHostsTarget
+			if target.Type == "dnsmasq" {
+				newFileTarget = newDnsmasqTarget
+			}
+			file, err := newFileTarget(target)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, file)
+		case "unbound":
+			unbound, err := newUnboundTarget(target)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, unbound)
+		default:
+			return nil, fmt.Errorf("unknown DNS target type %q for %s", target.Type, target.Name)
+		}
+	}
+	return targets, nil
+}
+
+// printChanges lists what a sync adds and removes, used for dry runs
+func printChanges(add []records.Record, remove []records.Record) {
+	for _, record := range add {
+		fmt.Printf("	Would add %s\n", record)
+	}
+	for _, record := range remove {
+		fmt.Printf("	Would remove %s\n", record)
+	}
+}
+
+// runCommand runs a command after a target has written its files, such as a reload of the DNS server
+func runCommand(command string) error {
+	fmt.Printf("	Running %s\n", command)
+	output, err := exec.Command("sh", "-c", command).CombinedOutput()
+	if len(output) != 0 {
+		fmt.Printf("	%s\n", strings.TrimSpace(string(output)))
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", command, err)
+	}
+	return nil
+}
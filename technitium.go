@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+	"unipidns/internal/technitium"
+)
+
+// technitiumComment marks the records this tool added, any record without it was made by hand
+const technitiumComment = "unipidns"
+
+// technitiumTarget publishes the records to zones on a Technitium DNS Server
+type technitiumTarget struct {
+	name   string
+	ptr    bool
+	client *technitium.Client
+}
+
+func newTechnitiumTarget(target DnsTarget) *technitiumTarget {
+	return &technitiumTarget{
+		name:   target.Name,
+		ptr:    target.Ptr,
+		client: technitium.NewClient(target.Url, target.Token, &http.Client{Timeout: target.timeout()}),
+	}
+}
+
+func (t *technitiumTarget) displayName() string {
+	return "Technitium: " + t.name
+}
+
+// sync makes sure the local zone exists and reconciles the A, AAAA and CNAME records in it, along with
+// any zone already on the server for one of the configured domains. Records with no zone to go in are
+// skipped. Only the enabled records carrying technitiumComment belong to this tool, so records made by
+// hand such as the address of the nameserver are left alone, as are names outside the configured domains.
+func (t *technitiumTarget) sync(desired *desiredState) error {
+	zones, err := t.client.GetZones()
+	if err != nil {
+		return err
+	}
+	var zoneNames []string
+	for _, zone := range zones {
+		if zone.Type == "Primary" || zone.Type == "Forwarder" {
+			zoneNames = append(zoneNames, strings.ToLower(zone.Name))
+		}
+	}
+	if !slices.Contains(zoneNames, desired.local) {
+		fmt.Printf("	Creating zone %s\n", desired.local)
+		if !desired.dryRun {
+			err = t.client.CreateZone(desired.local)
+			if err != nil {
+				return err
+			}
+		}
+		zoneNames = append(zoneNames, desired.local)
+	}
+
+	// the zones this tool manages, and where each desired record goes
+	managed := []string{desired.local}
+	for _, zone := range zoneNames {
+		if _, ok := domains.Match(desired.domains, zone); ok && zone != desired.local {
+			managed = append(managed, zone)
+		}
+	}
+	zoneOf := map[records.Record]string{}
+	var wanted []records.Record
+	skipped := 0
+	for _, record := range desired.records.Records {
+		zone := recordZone(record.Name, zoneNames)
+		if zone == "" {
+			skipped++
+			continue
+		}
+		zoneOf[record] = zone
+		wanted = append(wanted, record)
+	}
+	if skipped != 0 {
+		fmt.Printf("	%d Records Skipped, there is no zone for them\n", skipped)
+	}
+
+	var current []records.Record
+	// the records this tool added and may remove
+	managedRecords := map[records.Record]bool{}
+	for _, zone := range zoneNames {
+		if !slices.Contains(managed, zone) && !slices.ContainsFunc(wanted, func(r records.Record) bool { return zoneOf[r] == zone }) {
+			continue
+		}
+		zoneRecords, err := t.client.GetRecords(zone)
+		if err != nil && !(desired.dryRun && zone == desired.local) {
+			return err
+		}
+		for _, zoneRecord := range zoneRecords {
+			if zoneRecord.Type == "PTR" {
+				continue
+			}
+			record := records.Record{Type: zoneRecord.Type, Name: zoneRecord.Name, Value: zoneRecord.Value}
+			zoneOf[record] = zone
+			managedRecords[record] = !zoneRecord.Disabled && zoneRecord.Comments == technitiumComment
+			current = append(current, record)
+		}
+	}
+	fmt.Printf("	%d Records Found\n", len(current))
+
+	add, remove := records.Diff(current, wanted, func(record records.Record) bool {
+		if !managedRecords[record] || record.Name == zoneOf[record] {
+			return false
+		}
+		if zoneOf[record] == desired.local {
+			return true
+		}
+		_, ok := domains.Match(desired.domains, strings.TrimPrefix(record.Name, "*."))
+		return ok
+	})
+	fmt.Printf("	%d Records to Add\n", len(add))
+	fmt.Printf("	%d Records to Remove\n", len(remove))
+	if desired.dryRun {
+		printChanges(add, remove)
+		return nil
+	}
+
+	for _, record := range remove {
+		err = t.client.DeleteRecord(zoneOf[record], technitiumRecord(record))
+		if err != nil {
+			return err
+		}
+		if reverse, ok := records.ReverseName(record.Value); ok && t.ptr {
+			err = t.client.DeleteRecord("", technitium.Record{Name: reverse, Type: "PTR", Value: record.Name})
+			if err != nil {
+				fmt.Printf("	Unable to remove the PTR record for %s: %s\n", record.Name, err)
+			}
+		}
+	}
+	for _, record := range add {
+		added := technitiumRecord(record)
+		added.Comments = technitiumComment
+		err = t.client.AddRecord(zoneOf[record], added, t.ptr && !record.Wildcard())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordZone returns the most specific of zones that name is in, or an empty string
+func recordZone(name string, zones []string) string {
+	best := ""
+	for _, zone := range zones {
+		if records.InZone(name, zone) && len(zone) > len(best) {
+			best = zone
+		}
+	}
+	return best
+}
+
+func technitiumRecord(record records.Record) technitium.Record {
+	return technitium.Record{Name: record.Name, Type: record.Type, Value: record.Value}
+}
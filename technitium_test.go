@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"unipidns/internal/domains"
+	"unipidns/internal/records"
+)
+
+// technitiumServer is a fake Technitium DNS Server holding zones, which records the changes made to it
+type technitiumServer struct {
+	t       *testing.T
+	zones   map[string][]map[string]interface{}
+	changes []string
+}
+
+func (s *technitiumServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var response interface{} = map[string]interface{}{}
+	switch r.URL.Path {
+	case "/api/zones/list":
+		var zones []map[string]string
+		for zone := range s.zones {
+			zones = append(zones, map[string]string{"name": zone, "type": "Primary"})
+		}
+		response = map[string]interface{}{"zones": zones}
+	case "/api/zones/records/get":
+		zoneRecords, ok := s.zones[r.FormValue("zone")]
+		if !ok {
+			w.Write([]byte(`{"status": "error", "errorMessage": "No such zone was found"}`))
+			return
+		}
+		response = map[string]interface{}{"records": zoneRecords}
+	case "/api/zones/create", "/api/zones/records/add", "/api/zones/records/delete":
+		change := strings.TrimPrefix(r.URL.Path, "/api/zones/")
+		for _, param := range []string{"zone", "domain", "type", "ipAddress", "cname", "comments"} {
+			if value := r.FormValue(param); value != "" {
+				change += " " + value
+			}
+		}
+		s.changes = append(s.changes, change)
+	default:
+		s.t.Errorf("Unexpected path %s", r.URL.Path)
+	}
+	responseJson, err := json.Marshal(map[string]interface{}{"status": "ok", "response": response})
+	if err != nil {
+		s.t.Errorf("Error marshalling response: %s", err)
+	}
+	w.Write(responseJson)
+}
+
+func address(name string, ip string, comments string, disabled bool) map[string]interface{} {
+	return map[string]interface{}{"name": name, "type": "A", "comments": comments, "disabled": disabled, "rData": map[string]string{"ipAddress": ip}}
+}
+
+func TestTechnitiumSync(t *testing.T) {
+	fake := &technitiumServer{t: t, zones: map[string][]map[string]interface{}{
+		"lan": {
+			address("lan", "192.168.1.2", "", false),
+			address("ns.lan", "192.168.1.2", "", false),
+			address("nas.lan", "192.168.1.10", technitiumComment, false),
+			address("old.lan", "192.168.1.11", technitiumComment, false),
+			address("paused.lan", "192.168.1.12", technitiumComment, true),
+		},
+		"awesome.com":      {address("www.awesome.com", "203.0.113.10", "", false)},
+		"apps.awesome.com": {},
+		"other.net":        {},
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	desired := &desiredState{local: "lan", domains: []domains.Domain{{Name: "awesome.com"}}}
+	desired.records.Add(
+		records.Address("nas.lan", "192.168.1.10"),
+		records.Cname("files.awesome.com", "edge.lan"),
+		records.Cname("grafana.apps.awesome.com", "edge.lan"),
+		records.Cname("files.elsewhere.org", "edge.lan"),
+	)
+	err := newTechnitiumTarget(DnsTarget{source: source{Name: "test", Url: server.URL}}).sync(desired)
+	if err != nil {
+		t.Fatalf("Error syncing: %s", err)
+	}
+	slices.Sort(fake.changes)
+	expected := []string{
+		"records/add apps.awesome.com grafana.apps.awesome.com CNAME edge.lan unipidns",
+		"records/add awesome.com files.awesome.com CNAME edge.lan unipidns",
+		"records/delete lan old.lan A 192.168.1.11",
+	}
+	if !slices.Equal(fake.changes, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, fake.changes)
+	}
+}
+
+func TestTechnitiumDryRun(t *testing.T) {
+	fake := &technitiumServer{t: t, zones: map[string][]map[string]interface{}{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	desired := &desiredState{local: "lan", dryRun: true}
+	desired.records.Add(records.Address("nas.lan", "192.168.1.10"))
+	err := newTechnitiumTarget(DnsTarget{source: source{Name: "test", Url: server.URL}}).sync(desired)
+	if err != nil {
+		t.Fatalf("Error in dry run: %s", err)
+	}
+	if len(fake.changes) != 0 {
+		t.Errorf("Expected a dry run not to create the zone or records, got %v", fake.changes)
+	}
+}
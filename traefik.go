@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"unipidns/internal/traefik"
+)
+
+// addTraefikRecords adds the hostnames from the router rules of every Traefik instance to edges
+func addTraefikRecords(config *Config, edges *edgeRecords) error {
+	for _, instance := range config.Traefik {
+		name := instance.displayName()
+		fmt.Printf("Fetching routers from Traefik %s\n", name)
+		client := traefik.NewClient(instance.Url, instance.Username, instance.Password, &http.Client{Timeout: instance.timeout()})
+		routers, err := client.GetRouters()
+		if err != nil {
+			return err
+		}
+
+		instanceDomains := instance.Domains
+		if len(instanceDomains) == 0 {
+			instanceDomains = config.publicDomains()
+		}
+		defaultEdge := config.WebEdge
+		if instance.WebEdge != "" {
+			defaultEdge = instance.WebEdge
+		}
+
+		cnames := 0
+		skipped := 0
+		for _, router := range routers {
+			hosts := router.Hosts()
+			if !router.Enabled() {
+				if len(hosts) != 0 {
+					fmt.Printf("Skipping router %s (disabled)\n", router.Name)
+					skipped++
+				}
+				continue
+			}
+			for _, host := range hosts {
+				if edges.add(name, host, instanceDomains, defaultEdge, "") {
+					cnames++
+				}
+			}
+		}
+		if skipped != 0 {
+			fmt.Printf("%d Traefik Routers Skipped\n", skipped)
+		}
+		fmt.Printf("%d CNAME Hosts From Traefik Routers\n", cnames)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"unipidns/internal/managedfile"
+	"unipidns/internal/records"
+	"unipidns/internal/unbound"
+)
+
+// unboundTarget writes the records as an include file of local-data for unbound
+type unboundTarget struct {
+	name     string
+	path     string
+	zoneType string
+	command  string
+}
+
+func newUnboundTarget(target DnsTarget) (*unboundTarget, error) {
+	if target.Path == "" {
+		return nil, errors.New("unbound targets need a path")
+	}
+	zoneType := target.LocalZoneType
+	if zoneType == "" {
+		zoneType = "transparent"
+	}
+	return &unboundTarget{name: target.Name, path: target.Path, zoneType: zoneType, command: target.Command}, nil
+}
+
+func (u *unboundTarget) displayName() string {
+	return "Unbound: " + u.name
+}
+
+// sync writes the include file when it has changed and then runs the command. The file belongs
+// entirely to this tool. CNAMEs are flattened, as unbound does not follow a CNAME in local-data to
+// another local name.
+func (u *unboundTarget) sync(desired *desiredState) error {
+	flattened, unresolved := desired.records.Flatten()
+	if len(unresolved) != 0 {
+		fmt.Printf("	%d CNAMEs Skipped, their targets have no address here\n", len(unresolved))
+	}
+	config := unbound.Config{Zone: desired.local, ZoneType: u.zoneType}
+	var addressRecords []records.Record
+	for _, record := range flattened {
+		config.Records = append(config.Records, unbound.Record{Name: record.Name, Type: record.Type, Value: record.Value})
+		if !record.Wildcard() {
+			addressRecords = append(addressRecords, record)
+		}
+	}
+	// one PTR per address, pointing at the first name given to it
+	for _, group := range groupByAddress(addressRecords) {
+		config.Ptrs = append(config.Ptrs, unbound.Ptr{Address: group.address, Name: group.names[0]})
+	}
+
+	changed, err := managedfile.Update(u.path, unbound.Render(config), desired.dryRun)
+	if err != nil {
+		return err
+	}
+	status := "unchanged"
+	if changed && desired.dryRun {
+		status = "would be updated"
+	} else if changed {
+		status = "updated"
+	}
+	fmt.Printf("	%s %s, %d Records\n", u.path, status, len(config.Records))
+
+	if changed && u.command != "" && !desired.dryRun {
+		return runCommand(u.command)
+	}
+	return nil
+}
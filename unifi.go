@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unipidns/internal/unificontroller"
+)
+
+// buildHostRecords turns the Unifi clients and records into PiHole host ("ip host") and CNAME
+// ("host,target") lines. Client names are qualified with the local suffix.
+func buildHostRecords(config *Config, clients []unificontroller.Client, unifiRecords []unificontroller.Record) ([]string, []string) {
+	for idx, client := range clients {
+		clients[idx].Name = fmt.Sprintf("%s.%s", strings.ToLower(client.Name), config.Local)
+	}
+
+	aliasAsHost := config.Unifi.Names != nil && config.Unifi.Names.AliasMode == "host"
+	var hostRecords []string
+	var cnameRecords []string
+	for _, client := range clients {
+		for _, name := range clientHostNames(config, client) {
+			hostRecords = append(hostRecords, fmt.Sprintf("%s %s", client.Ip, name))
+			for _, ipv6 := range client.Ipv6 {
+				hostRecords = append(hostRecords, fmt.Sprintf("%s %s", ipv6, name))
+			}
+		}
+		if !aliasAsHost {
+			for _, alias := range client.Aliases {
+				cnameRecords = append(cnameRecords, fmt.Sprintf("%s.%s,%s", alias, config.Local, client.Name))
+			}
+		}
+	}
+	for _, record := range unifiRecords {
+		switch record.Type {
+		case "A", "AAAA":
+			host := fmt.Sprintf("%s %s", record.Value, record.Name)
+			if !slices.Contains(hostRecords, host) {
+				hostRecords = append(hostRecords, host)
+			}
+		case "CNAME":
+			cnameRecords = append(cnameRecords, fmt.Sprintf("%s,%s", record.Name, record.Value))
+		}
+	}
+	return hostRecords, cnameRecords
+}
+
+// clientHostNames returns every name that gets a host record for an already qualified client
+func clientHostNames(config *Config, client unificontroller.Client) []string {
+	names := []string{client.Name}
+	if client.LocalDnsRecord != "" && client.LocalDnsRecord != client.Name {
+		names = append(names, client.LocalDnsRecord)
+	}
+	if config.Unifi.Names != nil && config.Unifi.Names.AliasMode == "host" {
+		for _, alias := range client.Aliases {
+			names = append(names, fmt.Sprintf("%s.%s", alias, config.Local))
+		}
+	}
+	return names
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"time"
+	"unipidns/internal/records"
+	"unipidns/internal/zonefile"
+)
+
+// zoneFileTarget writes the records as zone files for BIND, NSD, Knot and friends to load
+type zoneFileTarget struct {
+	name         string
+	directory    string
+	zones        []string
+	reverseZones []string
+	nameserver   string
+	// nameserverAddress is the glue for a nameserver inside one of the zones
+	nameserverAddress string
+	hostmaster        string
+	ttl               uint32
+	command           string
+}
+
+func newZoneFileTarget(target DnsTarget, local string) (*zoneFileTarget, error) {
+	if target.Directory == "" || target.Nameserver == "" {
+		return nil, errors.New("zonefile targets need a directory and a nameserver")
+	}
+	if _, err := netip.ParseAddr(target.NameserverAddress); target.NameserverAddress != "" && err != nil {
+		return nil, fmt.Errorf("nameserverAddress %q of %s is not an IP address", target.NameserverAddress, target.Name)
+	}
+	zones := target.Zones
+	if len(zones) == 0 {
+		zones = []string{local}
+	}
+	return &zoneFileTarget{
+		name:              target.Name,
+		directory:         target.Directory,
+		zones:             zones,
+		reverseZones:      target.ReverseZones,
+		nameserver:        target.Nameserver,
+		nameserverAddress: target.NameserverAddress,
+		hostmaster:        target.Hostmaster,
+		ttl:               target.ttl(),
+		command:           target.Command,
+	}, nil
+}
+
+func (z *zoneFileTarget) displayName() string {
+	return "Zone Files: " + z.name
+}
+
+// sync renders a file for each zone and reverse zone, and runs the command if any of them changed.
+// The files belong entirely to this tool.
+func (z *zoneFileTarget) sync(desired *desiredState) error {
+	zoneRecords := map[string][]zonefile.Record{}
+	skipped := 0
+	for _, record := range desired.records.Records {
+		zone := recordZone(record.Name, z.zones)
+		if zone == "" {
+			skipped++
+			continue
+		}
+		zoneRecords[zone] = append(zoneRecords[zone], zonefile.Record{Name: record.Name, Type: record.Type, Value: record.Value})
+	}
+	for _, ptr := range desired.records.Ptrs() {
+		if zone := recordZone(ptr.Name, z.reverseZones); zone != "" {
+			zoneRecords[zone] = append(zoneRecords[zone], zonefile.Record{Name: ptr.Name, Type: records.PTR, Value: ptr.Value})
+		}
+	}
+	if skipped != 0 {
+		fmt.Printf("	%d Records Skipped, they are not in any of the zones\n", skipped)
+	}
+
+	for _, zone := range z.zones {
+		if !records.InZone(z.nameserver, zone) || z.nameserverAddress != "" {
+			continue
+		}
+		if !slices.ContainsFunc(zoneRecords[zone], func(r zonefile.Record) bool {
+			return r.Name == z.nameserver && (r.Type == records.A || r.Type == records.AAAA)
+		}) {
+			return fmt.Errorf("the nameserver %s is inside %s but has no address, set nameserverAddress or give it a fixed IP", z.nameserver, zone)
+		}
+	}
+
+	changed := false
+	for _, zone := range append(append([]string{}, z.zones...), z.reverseZones...) {
+		path := filepath.Join(z.directory, zonefile.FileName(zone))
+		written, err := zonefile.Write(path, zonefile.Zone{
+			Origin:            zone,
+			Nameserver:        z.nameserver,
+			NameserverAddress: z.nameserverAddress,
+			Hostmaster:        z.hostmaster,
+			TTL:               z.ttl,
+			Records:           zoneRecords[zone],
+		}, time.Now(), desired.dryRun)
+		if err != nil {
+			return err
+		}
+		status := "unchanged"
+		if written && desired.dryRun {
+			status = "would be updated"
+		} else if written {
+			status = "updated"
+		}
+		fmt.Printf("	%s %s, %d Records\n", path, status, len(zoneRecords[zone]))
+		changed = changed || written
+	}
+
+	if changed && z.command != "" && !desired.dryRun {
+		return runCommand(z.command)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unipidns/internal/records"
+)
+
+func TestZoneFileNameserverGlue(t *testing.T) {
+	directory := t.TempDir()
+	desired := &desiredState{local: "lan"}
+	desired.records.Add(records.Address("nas.lan", "192.168.1.10"))
+
+	target, err := newZoneFileTarget(DnsTarget{source: source{Name: "test"}, Directory: directory, Nameserver: "ns.lan"}, "lan")
+	if err != nil {
+		t.Fatalf("Error creating target: %s", err)
+	}
+	if err = target.sync(desired); err == nil {
+		t.Errorf("Expected an error for an in-zone nameserver without an address")
+	}
+
+	_, err = newZoneFileTarget(DnsTarget{source: source{Name: "test"}, Directory: directory, Nameserver: "ns.lan", NameserverAddress: "ns"}, "lan")
+	if err == nil {
+		t.Errorf("Expected an error for a nameserverAddress that is not an IP address")
+	}
+
+	target, err = newZoneFileTarget(DnsTarget{source: source{Name: "test"}, Directory: directory, Nameserver: "ns.lan", NameserverAddress: "192.168.1.2"}, "lan")
+	if err != nil {
+		t.Fatalf("Error creating target: %s", err)
+	}
+	if err = target.sync(desired); err != nil {
+		t.Fatalf("Error syncing: %s", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(directory, "lan.zone"))
+	if !strings.Contains(string(content), "ns.lan.\tIN\tA\t192.168.1.2\n") {
+		t.Errorf("Expected the glue record in\n%s", content)
+	}
+
+	// a fixed IP for the nameserver is enough without nameserverAddress
+	desired.records.Add(records.Address("ns.lan", "192.168.1.2"))
+	target.nameserverAddress = ""
+	if err = target.sync(desired); err != nil {
+		t.Errorf("Expected a desired address to satisfy the nameserver, got %s", err)
+	}
+}